@@ -0,0 +1,148 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/maxliu9403/ProxyHub/internal/config"
+	"github.com/maxliu9403/ProxyHub/internal/logic/proxy"
+	"github.com/maxliu9403/ProxyHub/internal/pkg/geoip"
+	"github.com/maxliu9403/common/apiserver/conf"
+	"github.com/spf13/cobra"
+)
+
+// newImportProxyCommand 从文件批量导入代理，与 POST /api/proxy/import 使用同一套解析与去重逻辑
+func newImportProxyCommand(configFile *string) *cobra.Command {
+	var (
+		params  proxy.ImportParams
+		mapping []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import_proxy <file>",
+		Short: "import proxies from a text, csv or clash yaml file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			content, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			params.Content = string(content)
+
+			params.Mapping = make(map[string]string, len(mapping))
+			for _, m := range mapping {
+				field, column, ok := strings.Cut(m, "=")
+				if !ok {
+					return fmt.Errorf("invalid --map %q, expect Field=Column", m)
+				}
+				params.Mapping[field] = column
+			}
+
+			if err := conf.LoadConfig(*configFile, config.G); err != nil {
+				return err
+			}
+			ctx := context.Background()
+			if _, err := config.G.MySQL.BuildMySQLClient(ctx); err != nil {
+				return err
+			}
+			if err := geoip.Init(config.G.GeoIP.CityDB, config.G.GeoIP.ASNDB); err != nil {
+				return err
+			}
+
+			// file 类型的 proxy-provider 按相对导入文件所在目录的路径读取，http 类型由命令行直接下载
+			svc := proxy.Svc{Ctx: ctx}
+			dir := filepath.Dir(args[0])
+			resp, err := svc.ImportWithLoader(params, func(kind, location string) ([]byte, error) {
+				if kind != proxy.ProviderFile {
+					return fetchProvider(ctx, location)
+				}
+				if !filepath.IsAbs(location) {
+					location = filepath.Join(dir, location)
+				}
+				return os.ReadFile(location)
+			})
+			if err != nil {
+				return err
+			}
+			printImportReport(resp, params.DryRun)
+			return nil
+		},
+	}
+
+	cmd.Flags().Int64Var(&params.GroupID, "group", 0, "group id the proxies belong to")
+	cmd.Flags().StringVar(&params.Source, "source", "", "proxy source, e.g. ipfoxy")
+	cmd.Flags().StringVar(&params.Format, "format", proxy.ImportFormatText, "input format: text | csv | clash (proxies and proxy-providers)")
+	cmd.Flags().StringSliceVar(&mapping, "map", nil, "csv column mapping, e.g. --map IP=host,Password=pwd")
+	cmd.Flags().IntVar(&params.Priority, "priority", 0, "priority for rows without one")
+	cmd.Flags().IntVar(&params.Weight, "weight", 0, "weight for rows without one")
+	cmd.Flags().BoolVar(&params.DryRun, "dry-run", false, "only validate and print the report")
+	_ = cmd.MarkFlagRequired("group")
+	_ = cmd.MarkFlagRequired("source")
+	return cmd
+}
+
+func printImportReport(resp *proxy.ImportResp, dryRun bool) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "LINE\tPROVIDER\tSTATUS\tADDRESS\tMESSAGE")
+	for _, l := range resp.Lines {
+		addr := ""
+		if l.IP != "" {
+			addr = fmt.Sprintf("%s:%d", l.IP, l.Port)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", l.Line, l.Provider, l.Status, addr, l.Message)
+	}
+	_ = w.Flush()
+
+	fmt.Printf("\naccepted: %d, duplicate: %d, malformed: %d, unsupported: %d",
+		resp.Accepted, resp.Duplicate, resp.Malformed, resp.Unsupported)
+	if !dryRun {
+		fmt.Printf(", created: %d", resp.CreatedCount)
+	}
+	fmt.Println()
+}
+
+// proxy-provider 下载限制
+const (
+	providerFetchTimeout = 30 * time.Second
+	providerMaxSize      = 10 << 20
+)
+
+// fetchProvider 下载 http 类型的 proxy-provider，只允许 http/https，限制超时和内容大小
+func fetchProvider(ctx context.Context, location string) ([]byte, error) {
+	u, err := url.Parse(location)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("proxy-provider 地址无效: %s", location)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, providerFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载 proxy-provider 失败: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, providerMaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > providerMaxSize {
+		return nil, fmt.Errorf("proxy-provider 内容超过 %d MB", providerMaxSize>>20)
+	}
+	return body, nil
+}
@@ -11,6 +11,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/maxliu9403/ProxyHub/internal/cron"
 	"github.com/maxliu9403/common/cronjob"
@@ -18,6 +19,8 @@ import (
 	"github.com/maxliu9403/ProxyHub/internal/common"
 	"github.com/maxliu9403/ProxyHub/internal/config"
 	"github.com/maxliu9403/ProxyHub/internal/handler"
+	"github.com/maxliu9403/ProxyHub/internal/pkg/geoip"
+	"github.com/maxliu9403/ProxyHub/internal/pkg/provider"
 	"github.com/maxliu9403/ProxyHub/models"
 	"github.com/maxliu9403/common/apiserver"
 	"github.com/maxliu9403/common/apiserver/conf"
@@ -40,11 +43,12 @@ var (
 	versionCommand = version.NewVerCommand(projectName)
 	envCommand     = apiserver.NewConfigEnvCommand(config.G)
 	initDB         = models.NewCreateDatabaseCommand(&configFile)
+	importProxy    = newImportProxyCommand(&configFile)
 )
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "configs/dev.yaml", "configuration file path")
-	rootCmd.AddCommand(versionCommand, envCommand, initDB)
+	rootCmd.AddCommand(versionCommand, envCommand, initDB, importProxy)
 }
 
 func run() (err error) {
@@ -56,6 +60,21 @@ func run() (err error) {
 		return fmt.Errorf("config file init failed: %s", err.Error())
 	}
 
+	// 加载本地 GeoIP 库
+	if err = geoip.Init(config.G.GeoIP.CityDB, config.G.GeoIP.ASNDB); err != nil {
+		return fmt.Errorf("geoip init failed: %s", err.Error())
+	}
+
+	// 注册代理服务商适配器
+	for _, p := range config.G.Providers {
+		provider.Register(provider.NewHTTPAdapter(provider.HTTPConfig{
+			Source:   p.Source,
+			Endpoint: p.Endpoint,
+			APIKey:   p.APIKey,
+			Timeout:  time.Duration(p.Timeout) * time.Second,
+		}))
+	}
+
 	// 数据表迁移，新增表时修改 AllTables
 	m := apiserver.Migration(models.AllTables)
 	server := apiserver.CreateNewServer(ctx, config.G.APIConfig, m)
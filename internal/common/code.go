@@ -40,6 +40,15 @@ const (
 	ErrUpdateEmulator
 	ErrGetSubscribe
 	ErrBuildTokenErrGroup
+	ErrCreateTemplate
+	ErrUpdateTemplate
+	ErrDeleteTemplate
+	ErrRollbackTemplate
+	ErrBindTemplate
+	ErrValidateTemplate
+	ErrPreviewTemplate
+	ErrNoGeoProxy
+	ErrExport
 )
 
 var codeMsg = map[RetCode]string{
@@ -73,6 +82,15 @@ var codeMsg = map[RetCode]string{
 	ErrUpdateEmulator:         "更新模拟器失败",
 	ErrGetSubscribe:           "获取订阅链接失败",
 	ErrBuildTokenErrGroup:     "创建Token失败，无效的组ID",
+	ErrCreateTemplate:         "创建模板失败",
+	ErrUpdateTemplate:         "更新模板失败",
+	ErrDeleteTemplate:         "删除模板失败",
+	ErrRollbackTemplate:       "回滚模板失败",
+	ErrBindTemplate:           "绑定模板失败",
+	ErrValidateTemplate:       "模板校验未通过",
+	ErrPreviewTemplate:        "模板预览失败",
+	ErrNoGeoProxy:             "没有符合模拟器地区要求的可用代理",
+	ErrExport:                 "导出失败",
 }
 
 func GetMsg(code RetCode) string {
@@ -15,7 +15,42 @@ import (
 )
 
 type CronJob struct {
-	ReleaseIpPeriod string `yaml:"release_ip" env:"ReleaseIpPeriod" env-default:"*/6 * * * *"`
+	ReleaseIpPeriod    string `yaml:"release_ip" env:"ReleaseIpPeriod" env-default:"*/6 * * * *"`
+	HealthCheckPeriod  string `yaml:"health_check" env:"HealthCheckPeriod" env-default:"*/5 * * * *"`
+	ExitIPCheckPeriod  string `yaml:"exit_ip_check" env:"ExitIPCheckPeriod" env-default:"0 * * * *"`
+	ProviderSyncPeriod string `yaml:"provider_sync" env:"ProviderSyncPeriod" env-default:"*/30 * * * *"`
+	ReplenishPeriod    string `yaml:"replenish" env:"ReplenishPeriod" env-default:"*/10 * * * *"`
+}
+
+type ExitIPCheckCfg struct {
+	Enable      bool   `yaml:"enable" env:"ExitIPCheckEnable" env-default:"false"`        // 是否启用出口IP检测，默认关闭
+	Endpoint    string `yaml:"endpoint" env:"ExitIPCheckEndpoint"`                        // 返回请求方IP的接口，支持纯文本或含 ip/origin/query 字段的 JSON，未配置时不启动检测
+	Timeout     int    `yaml:"timeout" env:"ExitIPCheckTimeout" env-default:"10"`         // 单次检测超时，单位秒
+	Concurrency int    `yaml:"concurrency" env:"ExitIPCheckConcurrency" env-default:"10"` // 最大并发检测数
+}
+
+type HealthCheckCfg struct {
+	Enable          bool   `yaml:"enable" env:"HealthCheckEnable" env-default:"true"`                  // 是否启用代理健康检测
+	Target          string `yaml:"target" env:"HealthCheckTarget" env-default:"www.gstatic.com:80"`    // 经代理 CONNECT 的目标地址
+	Timeout         int    `yaml:"timeout" env:"HealthCheckTimeout" env-default:"5"`                   // 单次检测超时，单位秒
+	Concurrency     int    `yaml:"concurrency" env:"HealthCheckConcurrency" env-default:"20"`          // 最大并发检测数
+	FailThreshold   int    `yaml:"fail_threshold" env:"HealthCheckFailThreshold" env-default:"3"`      // 连续失败多少次标记为不健康
+	LatencyKeepDays int    `yaml:"latency_keep_days" env:"HealthCheckLatencyKeepDays" env-default:"7"` // 延迟历史保留天数
+}
+
+type ProviderCfg struct {
+	Source   string `yaml:"source"`   // 来源类型，与代理的 Source 一致，如 ipfoxy
+	Endpoint string `yaml:"endpoint"` // 服务商（或前置网关）接口地址前缀
+	APIKey   string `yaml:"api_key"`  // 鉴权密钥
+	Timeout  int    `yaml:"timeout"`  // 单次请求超时，单位秒，默认 10
+	GroupID  int64  `yaml:"group_id"` // 同步时新发现的代理归入的分组
+	// 单次同步允许删除或标记下线的本地代理比例，超过时视为服务商返回的列表不完整，跳过删除，默认 0.5
+	MaxRemoveRatio float64 `yaml:"max_remove_ratio"`
+}
+
+type GeoIPCfg struct {
+	CityDB string `yaml:"city_db" env:"GeoIPCityDB"` // MaxMind 格式的 City 库路径，如 GeoLite2-City.mmdb，为空不启用
+	ASNDB  string `yaml:"asn_db" env:"GeoIPASNDB"`   // MaxMind 格式的 ASN 库路径，如 GeoLite2-ASN.mmdb，为空不启用
 }
 
 type MailCfg struct {
@@ -33,9 +68,13 @@ type CustomCfg struct {
 
 type Config struct {
 	apiserver.APIConfig `yaml:"base"`
-	CronJob             CronJob   `yaml:"cron_job"`
-	CustomCfg           CustomCfg `yaml:"custom_cfg"`
-	Mail                MailCfg   `yaml:"mailer"`
+	CronJob             CronJob        `yaml:"cron_job"`
+	CustomCfg           CustomCfg      `yaml:"custom_cfg"`
+	Mail                MailCfg        `yaml:"mailer"`
+	HealthCheck         HealthCheckCfg `yaml:"health_check"`
+	ExitIPCheck         ExitIPCheckCfg `yaml:"exit_ip_check"`
+	GeoIP               GeoIPCfg       `yaml:"geoip"`
+	Providers           []ProviderCfg  `yaml:"providers"`
 }
 
 func (c *Config) String() string {
@@ -0,0 +1,191 @@
+package cron
+
+import (
+	"context"
+	"time"
+
+	"github.com/maxliu9403/ProxyHub/internal/config"
+	"github.com/maxliu9403/ProxyHub/internal/pkg/geoip"
+	"github.com/maxliu9403/ProxyHub/internal/pkg/probe"
+	"github.com/maxliu9403/ProxyHub/models"
+	"github.com/maxliu9403/ProxyHub/models/factory"
+	"github.com/maxliu9403/ProxyHub/models/repo"
+	"github.com/maxliu9403/common/gormdb"
+	"github.com/maxliu9403/common/logger"
+	"gorm.io/gorm"
+)
+
+// ExitIPFunc 经代理查询出口 IP
+type ExitIPFunc func(ctx context.Context, proxy *models.Proxy) (string, error)
+
+type ExitIPCheckTaskSvc struct {
+	ctx    context.Context
+	db     *gorm.DB
+	lookup ExitIPFunc
+}
+
+func NewExitIPCheckTaskSvc(ctx context.Context) *ExitIPCheckTaskSvc {
+	return &ExitIPCheckTaskSvc{
+		ctx:    ctx,
+		db:     gormdb.Cli(ctx),
+		lookup: exitIPLookup,
+	}
+}
+
+func (s *ExitIPCheckTaskSvc) getProxyRepo() repo.ProxyRepo {
+	s.db = gormdb.Cli(s.ctx)
+	return factory.ProxyRepo(s.db)
+}
+
+// exitIPLookup 按配置的接口和超时经代理查询出口 IP
+func exitIPLookup(ctx context.Context, proxy *models.Proxy) (string, error) {
+	cfg := config.G.ExitIPCheck
+	return probe.ExitIP(ctx, probe.Target{
+		Server:   proxy.IP,
+		Port:     proxy.Port,
+		Username: proxy.Username,
+		Password: proxy.Password,
+	}, cfg.Endpoint, time.Duration(cfg.Timeout)*time.Second)
+}
+
+// ExitIPResult 单个代理的出口 IP 检测结果
+type ExitIPResult struct {
+	Proxy  *models.Proxy
+	ExitIP string
+	Err    error
+}
+
+// CheckAll 以有限并发检测所有代理的出口 IP，回写出口 IP 及变更、冲突标记
+func (s *ExitIPCheckTaskSvc) CheckAll() ([]ExitIPResult, error) {
+	proxies := make([]*models.Proxy, 0)
+	// 目前只能经 SOCKS5 探测，其他类型的代理不参与检测
+	query := models.GetListParams{ProxyTypes: []string{models.ProxyTypeSOCKS5}}
+	if _, err := s.getProxyRepo().GetList(query, &models.Proxy{}, &proxies); err != nil {
+		logger.ErrorfWithTrace(s.ctx, "出口IP检测：查询代理失败: %s", err.Error())
+		return nil, err
+	}
+
+	results := make([]ExitIPResult, len(proxies))
+	runBounded(len(proxies), config.G.ExitIPCheck.Concurrency, func(i int) {
+		exitIP, err := s.lookup(s.ctx, proxies[i])
+		results[i] = ExitIPResult{Proxy: proxies[i], ExitIP: exitIP, Err: err}
+	})
+
+	proxyRepo := s.getProxyRepo()
+	for _, r := range results {
+		if r.Err != nil {
+			logger.WarnfWithTrace(s.ctx, "出口IP检测：代理 %s 检测失败: %s", r.Proxy.IP, r.Err.Error())
+		}
+	}
+	previous := make(map[int64]string, len(results))
+	for _, r := range results {
+		previous[r.Proxy.ID] = r.Proxy.ExitIP
+	}
+	for id, fields := range exitIPFields(results, time.Now().Unix()) {
+		// 出口 IP 变化时按新的出口 IP 更新地理位置
+		if exitIP, ok := fields["exit_ip"].(string); ok && exitIP != previous[id] {
+			for k, v := range geoFields(exitIP) {
+				fields[k] = v
+			}
+		}
+		if fields["exit_ip_changed"] == true {
+			logger.WarnfWithTrace(s.ctx, "出口IP检测：代理 %d 出口IP已变更且未确认，当前为 %s", id, fields["exit_ip"])
+		}
+		if fields["exit_ip_conflict"] == true {
+			logger.WarnfWithTrace(s.ctx, "出口IP检测：代理 %d 出口IP与同组其他代理重复", id)
+		}
+		if err := proxyRepo.Update(id, fields); err != nil {
+			logger.ErrorfWithTrace(s.ctx, "出口IP检测：更新代理 %d 失败: %s", id, err.Error())
+		}
+	}
+	return results, nil
+}
+
+// exitIPFields 根据检测结果计算各代理需要回写的字段（key 为代理 ID）：
+//   - 检测成功的代理写入出口 IP，出口 IP 与上次检测（首次检测时与入口 IP）不一致时标记为已变更，
+//     变更标记保留到通过接口确认后才清除，之后的检测即使出口不再变化也不会自动清除；
+//   - 出口 IP 与同组其他代理相同时标记为冲突，检测失败的代理沿用上次的出口 IP 参与比对，仅在冲突标记变化时回写。
+func exitIPFields(results []ExitIPResult, now int64) map[int64]map[string]interface{} {
+	effective := make(map[int64]string, len(results))
+	counts := make(map[int64]map[string]int)
+	for _, r := range results {
+		exitIP := r.Proxy.ExitIP
+		if r.Err == nil {
+			exitIP = r.ExitIP
+		}
+		effective[r.Proxy.ID] = exitIP
+		if exitIP == "" {
+			continue
+		}
+		if counts[r.Proxy.GroupID] == nil {
+			counts[r.Proxy.GroupID] = make(map[string]int)
+		}
+		counts[r.Proxy.GroupID][exitIP]++
+	}
+
+	updates := make(map[int64]map[string]interface{})
+	for _, r := range results {
+		exitIP := effective[r.Proxy.ID]
+		conflict := exitIP != "" && counts[r.Proxy.GroupID][exitIP] > 1
+
+		if r.Err != nil {
+			if conflict != r.Proxy.ExitIPConflict {
+				updates[r.Proxy.ID] = map[string]interface{}{"exit_ip_conflict": conflict}
+			}
+			continue
+		}
+
+		previous := r.Proxy.ExitIP
+		if previous == "" {
+			previous = r.Proxy.IP
+		}
+		updates[r.Proxy.ID] = map[string]interface{}{
+			"exit_ip":            exitIP,
+			"exit_ip_changed":    r.Proxy.ExitIPChanged || exitIP != previous,
+			"exit_ip_conflict":   conflict,
+			"exit_ip_check_time": now,
+		}
+	}
+	return updates
+}
+
+// geoFields 查询 IP 的地理位置及 ASN 信息，GeoIP 未启用或未收录时返回空
+func geoFields(ip string) map[string]interface{} {
+	info, found := geoip.Lookup(ip)
+	if !found {
+		return nil
+	}
+	return map[string]interface{}{
+		"country":  info.Country,
+		"region":   info.Region,
+		"city":     info.City,
+		"timezone": info.Timezone,
+		"asn":      info.ASN,
+		"as_org":   info.ASOrg,
+	}
+}
+
+type ProxyExitIPCheckJob struct {
+	Svc *ExitIPCheckTaskSvc
+}
+
+func (j *ProxyExitIPCheckJob) Run() {
+	if !config.G.ExitIPCheck.Enable || config.G.ExitIPCheck.Endpoint == "" {
+		return
+	}
+
+	logger.Infof("开始执行定时任务：代理出口IP检测")
+	results, err := j.Svc.CheckAll()
+	if err != nil {
+		logger.Errorf("代理出口IP检测任务执行失败: %v", err)
+		return
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	logger.Infof("代理出口IP检测完成，共检测: %d，失败: %d", len(results), failed)
+}
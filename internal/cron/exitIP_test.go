@@ -0,0 +1,59 @@
+package cron
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+func TestExitIPFields(t *testing.T) {
+	proxy := func(id, groupID int64, ip, exitIP string, conflict bool) *models.Proxy {
+		return &models.Proxy{Meta: models.Meta{ID: id}, GroupID: groupID, IP: ip, ExitIP: exitIP, ExitIPConflict: conflict}
+	}
+	unacked := proxy(7, 3, "7.7.7.7", "6.6.6.6", false)
+	unacked.ExitIPChanged = true
+	errLookup := errors.New("timeout")
+
+	results := []ExitIPResult{
+		// 首次检测，出口与入口一致
+		{Proxy: proxy(1, 1, "1.1.1.1", "", false), ExitIP: "1.1.1.1"},
+		// 首次检测，出口与入口不一致
+		{Proxy: proxy(2, 1, "2.2.2.2", "", false), ExitIP: "9.9.9.9"},
+		// 出口与上次检测一致
+		{Proxy: proxy(3, 1, "3.3.3.3", "8.8.8.8", false), ExitIP: "8.8.8.8"},
+		// 检测失败，沿用上次的出口 IP 9.9.9.9 参与冲突比对
+		{Proxy: proxy(4, 1, "4.4.4.4", "9.9.9.9", false), Err: errLookup},
+		// 其他分组的相同出口不算冲突
+		{Proxy: proxy(5, 2, "5.5.5.5", "7.7.7.7", false), ExitIP: "8.8.8.8"},
+		// 检测失败且冲突标记未变化，不回写
+		{Proxy: proxy(6, 2, "6.6.6.6", "", false), Err: errLookup},
+		// 变更未确认，出口不再变化时也保留标记
+		{Proxy: unacked, ExitIP: "6.6.6.6"},
+	}
+
+	got := exitIPFields(results, 100)
+
+	want := map[int64]map[string]interface{}{
+		1: {"exit_ip": "1.1.1.1", "exit_ip_changed": false, "exit_ip_conflict": false, "exit_ip_check_time": int64(100)},
+		2: {"exit_ip": "9.9.9.9", "exit_ip_changed": true, "exit_ip_conflict": true, "exit_ip_check_time": int64(100)},
+		3: {"exit_ip": "8.8.8.8", "exit_ip_changed": false, "exit_ip_conflict": false, "exit_ip_check_time": int64(100)},
+		4: {"exit_ip_conflict": true},
+		5: {"exit_ip": "8.8.8.8", "exit_ip_changed": true, "exit_ip_conflict": false, "exit_ip_check_time": int64(100)},
+		7: {"exit_ip": "6.6.6.6", "exit_ip_changed": true, "exit_ip_conflict": false, "exit_ip_check_time": int64(100)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d updates, want %d: %v", len(got), len(want), got)
+	}
+	for id, fields := range want {
+		if len(got[id]) != len(fields) {
+			t.Errorf("代理 %d: got %v, want %v", id, got[id], fields)
+			continue
+		}
+		for k, v := range fields {
+			if got[id][k] != v {
+				t.Errorf("代理 %d 字段 %s: got %v, want %v", id, k, got[id][k], v)
+			}
+		}
+	}
+}
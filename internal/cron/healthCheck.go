@@ -0,0 +1,201 @@
+package cron
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/maxliu9403/ProxyHub/internal/config"
+	"github.com/maxliu9403/ProxyHub/internal/pkg/probe"
+	"github.com/maxliu9403/ProxyHub/models"
+	"github.com/maxliu9403/ProxyHub/models/factory"
+	"github.com/maxliu9403/ProxyHub/models/repo"
+	"github.com/maxliu9403/common/gormdb"
+	"github.com/maxliu9403/common/logger"
+	"gorm.io/gorm"
+)
+
+// ProbeFunc 探测单个代理，返回连接耗时
+type ProbeFunc func(ctx context.Context, proxy *models.Proxy) (time.Duration, error)
+
+type HealthCheckTaskSvc struct {
+	ctx   context.Context
+	db    *gorm.DB
+	probe ProbeFunc
+}
+
+func NewHealthCheckTaskSvc(ctx context.Context) *HealthCheckTaskSvc {
+	return &HealthCheckTaskSvc{
+		ctx:   ctx,
+		db:    gormdb.Cli(ctx),
+		probe: socks5Probe,
+	}
+}
+
+func (s *HealthCheckTaskSvc) getProxyRepo() repo.ProxyRepo {
+	s.db = gormdb.Cli(s.ctx)
+	return factory.ProxyRepo(s.db)
+}
+
+func (s *HealthCheckTaskSvc) getLatencyRepo() repo.ProxyLatencyRepo {
+	s.db = gormdb.Cli(s.ctx)
+	return factory.ProxyLatencyRepo(s.db)
+}
+
+// socks5Probe 按配置的目标地址和超时对代理做 SOCKS5 握手及认证 CONNECT
+func socks5Probe(ctx context.Context, proxy *models.Proxy) (time.Duration, error) {
+	cfg := config.G.HealthCheck
+	return probe.SOCKS5(ctx, probe.Target{
+		Server:   proxy.IP,
+		Port:     proxy.Port,
+		Username: proxy.Username,
+		Password: proxy.Password,
+	}, cfg.Target, time.Duration(cfg.Timeout)*time.Second)
+}
+
+// HealthCheckResult 单个代理的检测结果
+type HealthCheckResult struct {
+	Proxy   *models.Proxy
+	Latency time.Duration
+	Err     error
+}
+
+// CheckAll 以有限并发检测所有代理，并回写健康状态
+func (s *HealthCheckTaskSvc) CheckAll() ([]HealthCheckResult, error) {
+	proxies := make([]*models.Proxy, 0)
+	// 目前只能经 SOCKS5 探测，其他类型的代理不参与检测
+	query := models.GetListParams{ProxyTypes: []string{models.ProxyTypeSOCKS5}}
+	if _, err := s.getProxyRepo().GetList(query, &models.Proxy{}, &proxies); err != nil {
+		logger.ErrorfWithTrace(s.ctx, "健康检测：查询代理失败: %s", err.Error())
+		return nil, err
+	}
+
+	results := probeAll(s.ctx, proxies, config.G.HealthCheck.Concurrency, s.probe)
+
+	now := time.Now().Unix()
+	threshold := config.G.HealthCheck.FailThreshold
+	proxyRepo := s.getProxyRepo()
+	records := make([]*models.ProxyLatency, 0, len(results))
+	for _, r := range results {
+		if r.Err != nil {
+			logger.WarnfWithTrace(s.ctx, "健康检测：代理 %s 检测失败: %s", r.Proxy.IP, r.Err.Error())
+		}
+		fields := healthFields(r.Proxy, r.Latency, r.Err, threshold, now)
+		if err := proxyRepo.Update(r.Proxy.ID, fields); err != nil {
+			logger.ErrorfWithTrace(s.ctx, "健康检测：更新代理 %s 状态失败: %s", r.Proxy.IP, err.Error())
+		}
+		records = append(records, latencyRecord(r, now))
+	}
+
+	// 记录延迟历史并清理过期记录，失败不影响健康状态
+	latencyRepo := s.getLatencyRepo()
+	if err := latencyRepo.CreateBatch(records); err != nil {
+		logger.ErrorfWithTrace(s.ctx, "健康检测：写入延迟历史失败: %s", err.Error())
+	}
+	keepBefore := time.Now().AddDate(0, 0, -config.G.HealthCheck.LatencyKeepDays).Unix()
+	if err := latencyRepo.DeleteBefore(keepBefore); err != nil {
+		logger.ErrorfWithTrace(s.ctx, "健康检测：清理延迟历史失败: %s", err.Error())
+	}
+	return results, nil
+}
+
+// probeAll 以不超过 concurrency 的并发探测代理，结果顺序与输入一致
+func probeAll(ctx context.Context, proxies []*models.Proxy, concurrency int, probeFn ProbeFunc) []HealthCheckResult {
+	results := make([]HealthCheckResult, len(proxies))
+	runBounded(len(proxies), concurrency, func(i int) {
+		latency, err := probeFn(ctx, proxies[i])
+		results[i] = HealthCheckResult{Proxy: proxies[i], Latency: latency, Err: err}
+	})
+	return results
+}
+
+// runBounded 以不超过 concurrency 的并发对 [0, n) 执行 fn，全部完成后返回
+func runBounded(n, concurrency int, fn func(i int)) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// latencyMs 将耗时换算为毫秒，不足 1 毫秒按 1 毫秒计，0 保留给未知
+func latencyMs(d time.Duration) int64 {
+	if ms := d.Milliseconds(); ms > 0 {
+		return ms
+	}
+	return 1
+}
+
+// latencyRecord 将检测结果转换为延迟历史记录
+func latencyRecord(r HealthCheckResult, now int64) *models.ProxyLatency {
+	record := &models.ProxyLatency{
+		ProxyID:   r.Proxy.ID,
+		IP:        r.Proxy.IP,
+		Success:   r.Err == nil,
+		CheckTime: now,
+	}
+	if r.Err == nil {
+		record.LatencyMs = latencyMs(r.Latency)
+	}
+	return record
+}
+
+// healthFields 根据检测结果计算需要回写的字段：成功清零失败次数并记录延迟，失败累加，达到阈值标记为不健康
+func healthFields(proxy *models.Proxy, latency time.Duration, probeErr error, threshold int, now int64) map[string]interface{} {
+	if threshold <= 0 {
+		threshold = 1
+	}
+
+	fields := map[string]interface{}{"last_check_time": now}
+	if probeErr == nil {
+		fields["health_status"] = models.HealthHealthy
+		fields["fail_streak"] = 0
+		fields["latency_ms"] = latencyMs(latency)
+		return fields
+	}
+
+	streak := proxy.FailStreak + 1
+	fields["fail_streak"] = streak
+	if streak >= threshold {
+		fields["health_status"] = models.HealthUnhealthy
+	}
+	return fields
+}
+
+type ProxyHealthCheckJob struct {
+	Svc *HealthCheckTaskSvc
+}
+
+func (j *ProxyHealthCheckJob) Run() {
+	if !config.G.HealthCheck.Enable {
+		return
+	}
+
+	logger.Infof("开始执行定时任务：代理健康检测")
+	results, err := j.Svc.CheckAll()
+	if err != nil {
+		logger.Errorf("代理健康检测任务执行失败: %v", err)
+		return
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	logger.Infof("代理健康检测完成，共检测: %d，失败: %d", len(results), failed)
+}
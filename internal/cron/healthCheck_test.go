@@ -0,0 +1,81 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+func TestHealthFields(t *testing.T) {
+	errProbe := errors.New("connect refused")
+
+	fields := healthFields(&models.Proxy{FailStreak: 2, HealthStatus: models.HealthUnhealthy}, 120*time.Millisecond, nil, 3, 100)
+	if fields["health_status"] != models.HealthHealthy || fields["fail_streak"] != 0 ||
+		fields["last_check_time"] != int64(100) || fields["latency_ms"] != int64(120) {
+		t.Errorf("检测成功: %v", fields)
+	}
+
+	fields = healthFields(&models.Proxy{FailStreak: 0}, 0, errProbe, 3, 100)
+	if _, ok := fields["health_status"]; ok || fields["fail_streak"] != 1 || fields["latency_ms"] != nil {
+		t.Errorf("未达到阈值不应修改状态: %v", fields)
+	}
+
+	fields = healthFields(&models.Proxy{FailStreak: 2}, 0, errProbe, 3, 100)
+	if fields["health_status"] != models.HealthUnhealthy || fields["fail_streak"] != 3 {
+		t.Errorf("达到阈值应标记为不健康: %v", fields)
+	}
+}
+
+func TestLatencyRecord(t *testing.T) {
+	p := &models.Proxy{Meta: models.Meta{ID: 7}, IP: "10.0.0.1"}
+
+	r := latencyRecord(HealthCheckResult{Proxy: p, Latency: 300 * time.Microsecond}, 100)
+	if !r.Success || r.LatencyMs != 1 || r.ProxyID != 7 || r.CheckTime != 100 {
+		t.Errorf("不足 1 毫秒应按 1 毫秒记录: %+v", r)
+	}
+
+	r = latencyRecord(HealthCheckResult{Proxy: p, Latency: time.Second, Err: errors.New("timeout")}, 100)
+	if r.Success || r.LatencyMs != 0 {
+		t.Errorf("失败时不记录延迟: %+v", r)
+	}
+}
+
+func TestProbeAll(t *testing.T) {
+	proxies := make([]*models.Proxy, 10)
+	for i := range proxies {
+		proxies[i] = &models.Proxy{Meta: models.Meta{ID: int64(i)}}
+	}
+
+	var running, peak int32
+	results := probeAll(context.Background(), proxies, 3, func(_ context.Context, p *models.Proxy) (time.Duration, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if n <= old || atomic.CompareAndSwapInt32(&peak, old, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		if p.ID%2 == 1 {
+			return 0, errors.New("failed")
+		}
+		return time.Millisecond, nil
+	})
+
+	if peak > 3 {
+		t.Errorf("并发数超过限制: %d", peak)
+	}
+	for i, r := range results {
+		if r.Proxy != proxies[i] {
+			t.Fatalf("结果顺序与输入不一致: %d", i)
+		}
+		if (r.Err != nil) != (i%2 == 1) {
+			t.Errorf("代理 %d 结果错误: %v", i, r.Err)
+		}
+	}
+}
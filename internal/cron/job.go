@@ -5,6 +5,7 @@ import (
 
 	"github.com/maxliu9403/ProxyHub/internal/config"
 	"github.com/maxliu9403/common/cronjob"
+	"github.com/maxliu9403/common/logger"
 )
 
 func RegisterCronJobs(ctx context.Context) {
@@ -16,4 +17,37 @@ func RegisterCronJobs(ctx context.Context) {
 	if _, err := cronjob.CronJobs.AddJob(config.G.CronJob.ReleaseIpPeriod, job); err != nil {
 		panic("注册 ScanExpiredEmulatorJob 失败: " + err.Error())
 	}
+
+	healthCheckJob := &ProxyHealthCheckJob{
+		Svc: NewHealthCheckTaskSvc(ctx),
+	}
+	if _, err := cronjob.CronJobs.AddJob(config.G.CronJob.HealthCheckPeriod, healthCheckJob); err != nil {
+		panic("注册 ProxyHealthCheckJob 失败: " + err.Error())
+	}
+
+	// 出口IP检测需显式启用并配置检测接口，未配置接口时不启动
+	if cfg := config.G.ExitIPCheck; cfg.Enable && cfg.Endpoint == "" {
+		logger.Warnf("出口IP检测已启用但未配置 endpoint，不启动 ProxyExitIPCheckJob")
+	} else if cfg.Enable {
+		exitIPCheckJob := &ProxyExitIPCheckJob{
+			Svc: NewExitIPCheckTaskSvc(ctx),
+		}
+		if _, err := cronjob.CronJobs.AddJob(config.G.CronJob.ExitIPCheckPeriod, exitIPCheckJob); err != nil {
+			panic("注册 ProxyExitIPCheckJob 失败: " + err.Error())
+		}
+	}
+
+	providerSyncJob := &ProviderSyncJob{
+		Svc: NewProviderSyncTaskSvc(ctx),
+	}
+	if _, err := cronjob.CronJobs.AddJob(config.G.CronJob.ProviderSyncPeriod, providerSyncJob); err != nil {
+		panic("注册 ProviderSyncJob 失败: " + err.Error())
+	}
+
+	replenishJob := &ReplenishJob{
+		Svc: NewReplenishTaskSvc(ctx),
+	}
+	if _, err := cronjob.CronJobs.AddJob(config.G.CronJob.ReplenishPeriod, replenishJob); err != nil {
+		panic("注册 ReplenishJob 失败: " + err.Error())
+	}
 }
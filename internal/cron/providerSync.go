@@ -0,0 +1,307 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/maxliu9403/ProxyHub/internal/config"
+	"github.com/maxliu9403/ProxyHub/internal/pkg/geoip"
+	"github.com/maxliu9403/ProxyHub/internal/pkg/provider"
+	"github.com/maxliu9403/ProxyHub/models"
+	"github.com/maxliu9403/ProxyHub/models/factory"
+	"github.com/maxliu9403/ProxyHub/models/repo"
+	"github.com/maxliu9403/common/gormdb"
+	"github.com/maxliu9403/common/logger"
+	"gorm.io/gorm"
+)
+
+type ProviderSyncTaskSvc struct {
+	ctx context.Context
+	db  *gorm.DB
+}
+
+func NewProviderSyncTaskSvc(ctx context.Context) *ProviderSyncTaskSvc {
+	return &ProviderSyncTaskSvc{
+		ctx: ctx,
+		db:  gormdb.Cli(ctx),
+	}
+}
+
+func (s *ProviderSyncTaskSvc) getProxyRepo() repo.ProxyRepo {
+	s.db = gormdb.Cli(s.ctx)
+	return factory.ProxyRepo(s.db)
+}
+
+// ProviderSyncResult 单个服务商的同步结果
+type ProviderSyncResult struct {
+	Source   string
+	Created  int  // 服务商新增、本地补录的代理数
+	Updated  int  // 凭据或到期时间变化的代理数
+	Removed  int  // 服务商已不再提供、且未被使用而删除的代理数
+	Orphaned int  // 服务商已不再提供、但仍被模拟器使用而标记下线的代理数
+	Guarded  bool // 待删除比例超过阈值，本次跳过删除和下线
+	Err      error
+}
+
+// SyncAll 逐个同步配置的服务商
+func (s *ProviderSyncTaskSvc) SyncAll() []ProviderSyncResult {
+	results := make([]ProviderSyncResult, 0, len(config.G.Providers))
+	for _, cfg := range config.G.Providers {
+		adapter, ok := provider.Get(cfg.Source)
+		if !ok {
+			results = append(results, ProviderSyncResult{Source: cfg.Source, Err: fmt.Errorf("未注册的服务商 %s", cfg.Source)})
+			continue
+		}
+		results = append(results, s.Sync(adapter, cfg))
+	}
+	return results
+}
+
+// defaultMaxRemoveRatio 未配置 max_remove_ratio 时单次同步允许删除或下线的本地代理比例
+const defaultMaxRemoveRatio = 0.5
+
+// Sync 以服务商列出的代理为准，对齐本地来源为该服务商的代理，新发现的代理归入 cfg.GroupID
+func (s *ProviderSyncTaskSvc) Sync(adapter provider.Adapter, cfg config.ProviderCfg) ProviderSyncResult {
+	groupID := cfg.GroupID
+	result := ProviderSyncResult{Source: adapter.Source()}
+
+	remote, err := adapter.List(s.ctx)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	local := make([]*models.Proxy, 0)
+	if _, err := s.getProxyRepo().GetList(models.GetListParams{Sources: []string{adapter.Source()}}, &models.Proxy{}, &local); err != nil {
+		result.Err = fmt.Errorf("查询本地代理失败: %w", err)
+		return result
+	}
+
+	plan := planSync(local, remote)
+	if reason := removalGuard(len(local), len(remote), len(plan.remove)+len(plan.orphan), cfg.MaxRemoveRatio); reason != "" {
+		logger.WarnfWithTrace(s.ctx, "服务商同步：%s %s，跳过删除 %d 个、下线 %d 个代理",
+			adapter.Source(), reason, len(plan.remove), len(plan.orphan))
+		plan.remove, plan.orphan = nil, nil
+		result.Guarded = true
+	}
+	// 其他来源已占用的 IP 不再创建，与导入去重保持一致
+	if len(plan.create) > 0 {
+		ips := make([]string, 0, len(plan.create))
+		for _, p := range plan.create {
+			ips = append(ips, p.IP)
+		}
+		taken := make([]*models.Proxy, 0)
+		if _, err := s.getProxyRepo().GetList(models.GetListParams{IPs: ips}, &models.Proxy{}, &taken); err != nil {
+			result.Err = fmt.Errorf("查询本地代理失败: %w", err)
+			return result
+		}
+		if skipped := plan.dropTaken(taken); skipped > 0 {
+			logger.WarnfWithTrace(s.ctx, "服务商同步：%s 有 %d 个代理的 IP 已被其他来源占用，跳过创建", adapter.Source(), skipped)
+		}
+	}
+	if len(plan.create) > 0 && groupID <= 0 {
+		logger.WarnfWithTrace(s.ctx, "服务商同步：%s 未配置 group_id，跳过 %d 个新代理", adapter.Source(), len(plan.create))
+		plan.create = nil
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		proxyRepo := factory.ProxyRepo(tx)
+
+		toCreate := make([]*models.Proxy, 0, len(plan.create))
+		for _, p := range plan.create {
+			toCreate = append(toCreate, newProviderProxy(p, adapter.Source(), groupID))
+		}
+		if err := proxyRepo.CreateBatch(toCreate); err != nil {
+			return fmt.Errorf("创建代理失败: %w", err)
+		}
+
+		for id, fields := range plan.update {
+			if err := proxyRepo.Update(id, fields); err != nil {
+				return fmt.Errorf("更新代理 %d 失败: %w", id, err)
+			}
+		}
+
+		removeIDs := make([]int64, 0, len(plan.remove))
+		for _, p := range plan.remove {
+			removeIDs = append(removeIDs, p.ID)
+		}
+		if len(removeIDs) > 0 {
+			if err := proxyRepo.Deletes(removeIDs); err != nil {
+				return fmt.Errorf("删除代理失败: %w", err)
+			}
+		}
+
+		// 仍被使用的代理不直接删除，标记下线使其不再参与分配，模拟器下次订阅时切换；
+		// 下线标记与健康状态分开保存，健康检测恢复不会让它重新可分配，使用数归零后由后续同步删除
+		for _, p := range plan.orphan {
+			if err := proxyRepo.Update(p.ID, map[string]interface{}{"orphaned": true}); err != nil {
+				return fmt.Errorf("标记代理 %s 失败: %w", p.IP, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	result.Created = len(plan.create)
+	result.Updated = len(plan.update)
+	result.Removed = len(plan.remove)
+	result.Orphaned = len(plan.orphan)
+	return result
+}
+
+// syncPlan 本地代理与服务商代理的对齐方案
+type syncPlan struct {
+	create []provider.Proxy                 // 服务商有、本地没有
+	update map[int64]map[string]interface{} // 两边都有、需要回写的字段（key 为代理 ID）
+	remove []*models.Proxy                  // 本地有、服务商没有，且未被使用
+	orphan []*models.Proxy                  // 本地有、服务商没有，但仍被使用且尚未标记下线
+}
+
+// planSync 按 IP 对齐本地代理与服务商代理，与导入去重、绑定和冷却使用同一键，
+// 同一 IP 只保留第一条，端口变化作为字段回写。服务商更换了 IP 的代理视为一删一增，
+// 避免改写已绑定模拟器的 IP。
+func planSync(local []*models.Proxy, remote []provider.Proxy) syncPlan {
+	plan := syncPlan{update: make(map[int64]map[string]interface{})}
+
+	byIP := make(map[string]*models.Proxy, len(local))
+	for _, p := range local {
+		byIP[p.IP] = p
+	}
+
+	seen := make(map[string]bool, len(remote))
+	for _, r := range remote {
+		if seen[r.IP] {
+			continue
+		}
+		seen[r.IP] = true
+
+		p, ok := byIP[r.IP]
+		if !ok {
+			plan.create = append(plan.create, r)
+			continue
+		}
+		fields := map[string]interface{}{}
+		if r.Port != p.Port {
+			fields["port"] = r.Port
+		}
+		if r.Username != p.Username {
+			fields["username"] = r.Username
+		}
+		if r.Password != p.Password {
+			fields["password"] = r.Password
+		}
+		if r.ID != p.ProviderID {
+			fields["provider_id"] = r.ID
+		}
+		if r.ExpireTime != p.ExpireTime {
+			fields["expire_time"] = r.ExpireTime
+		}
+		// 服务商重新提供了曾下线的代理
+		if p.Orphaned {
+			fields["orphaned"] = false
+		}
+		if len(fields) > 0 {
+			plan.update[p.ID] = fields
+		}
+	}
+
+	for ip, p := range byIP {
+		if seen[ip] {
+			continue
+		}
+		switch {
+		case p.InUseCount == 0:
+			plan.remove = append(plan.remove, p)
+		case !p.Orphaned:
+			plan.orphan = append(plan.orphan, p)
+		}
+	}
+	return plan
+}
+
+// dropTaken 从待创建列表中去掉 IP 已存在的代理，返回去掉的数量
+func (plan *syncPlan) dropTaken(taken []*models.Proxy) int {
+	exists := make(map[string]bool, len(taken))
+	for _, p := range taken {
+		exists[p.IP] = true
+	}
+	create := plan.create[:0]
+	for _, r := range plan.create {
+		if !exists[r.IP] {
+			create = append(create, r)
+		}
+	}
+	skipped := len(plan.create) - len(create)
+	plan.create = create
+	return skipped
+}
+
+// removalGuard 服务商返回空列表，或待删除/下线的代理占本地比例超过 maxRatio 时，
+// 视为服务商接口异常或列表被截断，返回原因；为空表示可以正常删除
+func removalGuard(local, remote, removals int, maxRatio float64) string {
+	if removals == 0 {
+		return ""
+	}
+	if remote == 0 {
+		return "返回的代理列表为空"
+	}
+	if maxRatio <= 0 {
+		maxRatio = defaultMaxRemoveRatio
+	}
+	if ratio := float64(removals) / float64(local); ratio > maxRatio {
+		return fmt.Sprintf("待删除代理占比 %.0f%% 超过阈值 %.0f%%", ratio*100, maxRatio*100)
+	}
+	return ""
+}
+
+// newProviderProxy 由服务商代理构造本地代理，并按入口 IP 补全地理位置
+func newProviderProxy(p provider.Proxy, source string, groupID int64) *models.Proxy {
+	proxyType := p.ProxyType
+	if proxyType == "" {
+		proxyType = "socks5"
+	}
+	model := &models.Proxy{
+		IP:         p.IP,
+		Port:       p.Port,
+		Username:   p.Username,
+		Password:   p.Password,
+		ProxyType:  proxyType,
+		GroupID:    groupID,
+		Source:     source,
+		Weight:     1,
+		ProviderID: p.ID,
+		ExpireTime: p.ExpireTime,
+	}
+	if info, found := geoip.Lookup(p.IP); found {
+		model.Country = info.Country
+		model.Region = info.Region
+		model.City = info.City
+		model.Timezone = info.Timezone
+		model.ASN = info.ASN
+		model.ASOrg = info.ASOrg
+	}
+	return model
+}
+
+type ProviderSyncJob struct {
+	Svc *ProviderSyncTaskSvc
+}
+
+func (j *ProviderSyncJob) Run() {
+	if len(config.G.Providers) == 0 {
+		return
+	}
+
+	logger.Infof("开始执行定时任务：代理服务商同步")
+	for _, r := range j.Svc.SyncAll() {
+		if r.Err != nil {
+			logger.Errorf("服务商 %s 同步失败: %v", r.Source, r.Err)
+			continue
+		}
+		logger.Infof("服务商 %s 同步完成，新增 %d，更新 %d，删除 %d，下线仍在使用 %d，跳过删除 %t",
+			r.Source, r.Created, r.Updated, r.Removed, r.Orphaned, r.Guarded)
+	}
+}
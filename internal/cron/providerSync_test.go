@@ -0,0 +1,120 @@
+package cron
+
+import (
+	"testing"
+
+	"github.com/maxliu9403/ProxyHub/internal/pkg/provider"
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+func TestPlanSync(t *testing.T) {
+	local := []*models.Proxy{
+		{Meta: models.Meta{ID: 1}, IP: "10.0.0.1", Port: 1080, Username: "u", Password: "p", ProviderID: "a"},
+		{Meta: models.Meta{ID: 2}, IP: "10.0.0.2", Port: 1080, Username: "u", Password: "old", ProviderID: "b"},
+		{Meta: models.Meta{ID: 3}, IP: "10.0.0.3", Port: 1080, InUseCount: 0},
+		{Meta: models.Meta{ID: 4}, IP: "10.0.0.4", Port: 1080, InUseCount: 2},
+	}
+	remote := []provider.Proxy{
+		{ID: "a", IP: "10.0.0.1", Port: 1080, Username: "u", Password: "p"},
+		{ID: "b", IP: "10.0.0.2", Port: 1080, Username: "u", Password: "new", ExpireTime: 100},
+		{ID: "c", IP: "10.0.0.5", Port: 1080},
+		{ID: "c", IP: "10.0.0.5", Port: 1080}, // 重复条目只处理一次
+		{ID: "d", IP: "10.0.0.5", Port: 1081}, // 按 IP 对齐，同一 IP 的其他端口视为重复
+	}
+
+	plan := planSync(local, remote)
+
+	if len(plan.create) != 1 || plan.create[0].IP != "10.0.0.5" {
+		t.Errorf("create = %+v", plan.create)
+	}
+	if len(plan.update) != 1 {
+		t.Fatalf("update = %+v", plan.update)
+	}
+	if f := plan.update[2]; f["password"] != "new" || f["expire_time"] != int64(100) || len(f) != 2 {
+		t.Errorf("update[2] = %+v", f)
+	}
+	if len(plan.remove) != 1 || plan.remove[0].ID != 3 {
+		t.Errorf("remove = %+v", plan.remove)
+	}
+	if len(plan.orphan) != 1 || plan.orphan[0].ID != 4 {
+		t.Errorf("orphan = %+v", plan.orphan)
+	}
+}
+
+// 服务商只更换端口时回写端口，不视为一删一增
+func TestPlanSyncPortChanged(t *testing.T) {
+	local := []*models.Proxy{{Meta: models.Meta{ID: 1}, IP: "10.0.0.1", Port: 1080, ProviderID: "a", InUseCount: 1}}
+	remote := []provider.Proxy{{ID: "a", IP: "10.0.0.1", Port: 2080}}
+
+	plan := planSync(local, remote)
+	if len(plan.create) != 0 || len(plan.remove) != 0 || len(plan.orphan) != 0 {
+		t.Errorf("port change should not recreate the proxy: %+v", plan)
+	}
+	if f := plan.update[1]; f["port"] != int64(2080) || len(f) != 1 {
+		t.Errorf("update[1] = %+v", f)
+	}
+}
+
+func TestSyncPlanDropTaken(t *testing.T) {
+	plan := syncPlan{create: []provider.Proxy{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}}}
+	if n := plan.dropTaken([]*models.Proxy{{IP: "10.0.0.1", Source: "other"}}); n != 1 {
+		t.Errorf("dropped %d, want 1", n)
+	}
+	if len(plan.create) != 1 || plan.create[0].IP != "10.0.0.2" {
+		t.Errorf("create = %+v", plan.create)
+	}
+}
+
+func TestNewProviderProxy(t *testing.T) {
+	p := newProviderProxy(provider.Proxy{ID: "x", IP: "10.0.0.9", Port: 1081, Username: "u", Password: "p", ExpireTime: 5}, "ipfoxy", 7)
+	if p.Source != "ipfoxy" || p.GroupID != 7 || p.ProviderID != "x" || p.ProxyType != "socks5" || p.Weight != 1 || p.ExpireTime != 5 {
+		t.Errorf("unexpected proxy: %+v", p)
+	}
+}
+
+func TestPlanSyncOrphaned(t *testing.T) {
+	local := []*models.Proxy{
+		{Meta: models.Meta{ID: 1}, IP: "10.0.0.1", Port: 1080, InUseCount: 1, Orphaned: true},
+		{Meta: models.Meta{ID: 2}, IP: "10.0.0.2", Port: 1080, InUseCount: 1, Orphaned: true},
+		{Meta: models.Meta{ID: 3}, IP: "10.0.0.3", Port: 1080, Orphaned: true},
+	}
+	remote := []provider.Proxy{{IP: "10.0.0.1", Port: 1080}}
+
+	plan := planSync(local, remote)
+	if f := plan.update[1]; f["orphaned"] != false {
+		t.Errorf("re-listed proxy should be restored: %+v", plan.update)
+	}
+	if len(plan.orphan) != 0 {
+		t.Errorf("already orphaned proxy should not be marked again: %+v", plan.orphan)
+	}
+	if len(plan.remove) != 1 || plan.remove[0].ID != 3 {
+		t.Errorf("orphaned proxy no longer in use should be removed: %+v", plan.remove)
+	}
+
+	// 下线标记独立于健康状态，健康检测成功也不能让它重新可分配
+	p := local[1]
+	p.HealthStatus = models.HealthHealthy
+	if _, ok := healthFields(p, 0, nil, 3, 0)["orphaned"]; ok || p.Usable() {
+		t.Errorf("orphaned proxy should stay unusable after a healthy probe")
+	}
+}
+
+func TestRemovalGuard(t *testing.T) {
+	cases := []struct {
+		local, remote, removals int
+		ratio                   float64
+		guarded                 bool
+	}{
+		{10, 0, 10, 0, true},   // 服务商返回空列表
+		{10, 4, 6, 0, true},    // 超过默认 50%
+		{10, 6, 4, 0, false},   // 未超过默认阈值
+		{10, 2, 8, 0.9, false}, // 自定义阈值
+		{10, 5, 0, 0, false},   // 无需删除
+		{0, 0, 0, 0, false},    // 本地没有代理
+	}
+	for _, c := range cases {
+		if got := removalGuard(c.local, c.remote, c.removals, c.ratio) != ""; got != c.guarded {
+			t.Errorf("removalGuard(%d, %d, %d, %v) guarded = %v, want %v", c.local, c.remote, c.removals, c.ratio, got, c.guarded)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/maxliu9403/ProxyHub/internal/pkg/provider"
+	"github.com/maxliu9403/ProxyHub/models"
+	"github.com/maxliu9403/ProxyHub/models/factory"
+	"github.com/maxliu9403/ProxyHub/models/repo"
+	"github.com/maxliu9403/common/gormdb"
+	"github.com/maxliu9403/common/logger"
+	"gorm.io/gorm"
+)
+
+type ReplenishTaskSvc struct {
+	ctx context.Context
+	db  *gorm.DB
+}
+
+func NewReplenishTaskSvc(ctx context.Context) *ReplenishTaskSvc {
+	return &ReplenishTaskSvc{
+		ctx: ctx,
+		db:  gormdb.Cli(ctx),
+	}
+}
+
+func (s *ReplenishTaskSvc) getGroupRepo() repo.GroupsRepo {
+	s.db = gormdb.Cli(s.ctx)
+	return factory.GroupsRepo(s.db)
+}
+
+func (s *ReplenishTaskSvc) getProxyRepo() repo.ProxyRepo {
+	s.db = gormdb.Cli(s.ctx)
+	return factory.ProxyRepo(s.db)
+}
+
+// ReplenishResult 单个分组的补充/释放结果
+type ReplenishResult struct {
+	GroupName string
+	Source    string
+	Bought    int // 新购并加入分组的代理数
+	Released  int // 释放回服务商的代理数
+	Err       error
+}
+
+// ReplenishAll 检查所有配置了自动补充的分组
+func (s *ReplenishTaskSvc) ReplenishAll() ([]ReplenishResult, error) {
+	groups := make([]*models.Groups, 0)
+	if _, err := s.getGroupRepo().GetList(models.GetGroupListParams{}, &models.Groups{}, &groups); err != nil {
+		logger.ErrorfWithTrace(s.ctx, "代理池补充：查询分组失败: %s", err.Error())
+		return nil, err
+	}
+
+	results := make([]ReplenishResult, 0)
+	for _, g := range groups {
+		if g.ReplenishProvider == "" {
+			continue
+		}
+		results = append(results, s.Replenish(g))
+	}
+	return results, nil
+}
+
+// Replenish 按分组的补充规则向服务商购买或释放代理
+func (s *ReplenishTaskSvc) Replenish(group *models.Groups) ReplenishResult {
+	result := ReplenishResult{GroupName: group.Name, Source: group.ReplenishProvider}
+
+	adapter, ok := provider.Get(group.ReplenishProvider)
+	if !ok {
+		result.Err = fmt.Errorf("未注册的服务商 %s", group.ReplenishProvider)
+		return result
+	}
+
+	proxies := make([]*models.Proxy, 0)
+	if _, err := s.getProxyRepo().GetList(models.GetListParams{GroupIDs: []int64{group.ID}}, &models.Proxy{}, &proxies); err != nil {
+		result.Err = fmt.Errorf("查询分组代理失败: %w", err)
+		return result
+	}
+
+	buy, release := planReplenish(group, proxies, time.Now().Unix())
+
+	if buy > 0 {
+		bought, err := adapter.Purchase(s.ctx, provider.PurchaseRequest{Count: buy, Days: group.PurchaseDays()})
+		if err != nil {
+			result.Err = fmt.Errorf("购买代理失败: %w", err)
+			return result
+		}
+		toCreate := make([]*models.Proxy, 0, len(bought))
+		for _, p := range bought {
+			toCreate = append(toCreate, newProviderProxy(p, adapter.Source(), group.ID))
+		}
+		if err := s.getProxyRepo().CreateBatch(toCreate); err != nil {
+			// 服务商侧已购买成功，下次服务商同步时会补录，但会归入同步配置的分组
+			result.Err = fmt.Errorf("保存新购代理失败: %w", err)
+			return result
+		}
+		result.Bought = len(toCreate)
+	}
+
+	if len(release) > 0 {
+		ids := make([]string, 0, len(release))
+		localIDs := make([]int64, 0, len(release))
+		for _, p := range release {
+			ids = append(ids, p.ProviderID)
+			localIDs = append(localIDs, p.ID)
+		}
+		if err := adapter.Release(s.ctx, ids); err != nil {
+			result.Err = fmt.Errorf("释放代理失败: %w", err)
+			return result
+		}
+		// 服务商侧已释放，本地删除失败时由下次服务商同步清理
+		if err := s.getProxyRepo().Deletes(localIDs); err != nil {
+			result.Err = fmt.Errorf("删除已释放代理失败: %w", err)
+			return result
+		}
+		result.Released = len(release)
+	}
+	return result
+}
+
+// planReplenish 计算分组需要购买的代理数和可释放的闲置代理。
+// 只有可用（非不健康）的代理计入容量，每个代理提供 MaxOnline 个名额。分组需要的容量为
+// 使所用名额不超过目标使用率、且空闲名额不少于 MinFreeSlots 的最小值：
+//   - 容量不足时按 MaxOnline 向上取整购买，受 ReplenishMaxProxies 限制；
+//   - 容量富余一个代理以上时，释放来自该服务商、未被使用且不在冷却期的代理，优先释放优先级低、即将到期的。
+func planReplenish(group *models.Groups, proxies []*models.Proxy, now int64) (buy int, release []*models.Proxy) {
+	perProxy := int64(group.MaxOnline)
+	if perProxy <= 0 || (group.TargetUtilization <= 0 && group.MinFreeSlots <= 0) {
+		return 0, nil
+	}
+
+	var capacity, used int64
+	usable := 0
+	for _, p := range proxies {
+		used += p.InUseCount
+		if p.Usable() {
+			capacity += perProxy
+			usable++
+		}
+	}
+
+	required := used + int64(group.MinFreeSlots)
+	if group.TargetUtilization > 0 {
+		if byUtil := (used*100 + int64(group.TargetUtilization) - 1) / int64(group.TargetUtilization); byUtil > required {
+			required = byUtil
+		}
+	}
+
+	if capacity < required {
+		buy = int((required - capacity + perProxy - 1) / perProxy)
+		if group.ReplenishMaxProxies > 0 && usable+buy > group.ReplenishMaxProxies {
+			buy = group.ReplenishMaxProxies - usable
+		}
+		if buy < 0 {
+			buy = 0
+		}
+		return buy, nil
+	}
+
+	surplus := int((capacity - required) / perProxy)
+	if surplus == 0 {
+		return 0, nil
+	}
+
+	idle := make([]*models.Proxy, 0)
+	for _, p := range proxies {
+		if p.Source == group.ReplenishProvider && p.ProviderID != "" && p.InUseCount == 0 && p.Usable() && !p.InCooldown(now) {
+			idle = append(idle, p)
+		}
+	}
+	sort.SliceStable(idle, func(i, j int) bool {
+		if idle[i].Priority != idle[j].Priority {
+			return idle[i].Priority < idle[j].Priority
+		}
+		return idle[i].ExpireTime < idle[j].ExpireTime
+	})
+	if len(idle) > surplus {
+		idle = idle[:surplus]
+	}
+	return 0, idle
+}
+
+type ReplenishJob struct {
+	Svc *ReplenishTaskSvc
+}
+
+func (j *ReplenishJob) Run() {
+	logger.Infof("开始执行定时任务：分组代理池补充")
+	results, err := j.Svc.ReplenishAll()
+	if err != nil {
+		logger.Errorf("分组代理池补充任务执行失败: %v", err)
+		return
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			logger.Errorf("分组 %s 从 %s 补充代理失败: %v", r.GroupName, r.Source, r.Err)
+			continue
+		}
+		if r.Bought > 0 || r.Released > 0 {
+			logger.Infof("分组 %s 从 %s 新购 %d 个代理，释放 %d 个闲置代理", r.GroupName, r.Source, r.Bought, r.Released)
+		}
+	}
+}
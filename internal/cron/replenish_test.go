@@ -0,0 +1,81 @@
+package cron
+
+import (
+	"testing"
+
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+func replenishProxies(inUse ...int64) []*models.Proxy {
+	proxies := make([]*models.Proxy, 0, len(inUse))
+	for i, n := range inUse {
+		proxies = append(proxies, &models.Proxy{
+			Meta:       models.Meta{ID: int64(i + 1)},
+			InUseCount: n,
+			Source:     "fake",
+			ProviderID: string(rune('a' + i)),
+		})
+	}
+	return proxies
+}
+
+func TestPlanReplenishBuy(t *testing.T) {
+	group := &models.Groups{MaxOnline: 2, ReplenishProvider: "fake", TargetUtilization: 80, MinFreeSlots: 1}
+
+	// 容量 4，已用 4：按 80% 需要 5 个名额，按空闲名额需要 5 个，补 1 个代理
+	buy, release := planReplenish(group, replenishProxies(2, 2), 0)
+	if buy != 1 || release != nil {
+		t.Errorf("buy=%d release=%v, want 1, nil", buy, release)
+	}
+
+	// 不健康的代理不计入容量
+	proxies := replenishProxies(2, 0)
+	proxies[1].HealthStatus = models.HealthUnhealthy
+	if buy, _ := planReplenish(group, proxies, 0); buy != 1 {
+		t.Errorf("buy=%d, want 1", buy)
+	}
+
+	// 受分组代理数上限约束
+	group.ReplenishMaxProxies = 2
+	if buy, _ := planReplenish(group, replenishProxies(2, 2), 0); buy != 0 {
+		t.Errorf("buy=%d, want 0 when capped", buy)
+	}
+}
+
+func TestPlanReplenishRelease(t *testing.T) {
+	group := &models.Groups{MaxOnline: 2, ReplenishProvider: "fake", MinFreeSlots: 1}
+	proxies := replenishProxies(1, 0, 0, 0)
+	proxies[1].Priority = 1
+	proxies[2].CooldownUntil = 100 // 冷却中不释放
+	proxies[3].ExpireTime = 50
+
+	// 容量 8，需要 2，富余 3 个代理，但只有 .2 和 .4 可释放，低优先级优先
+	buy, release := planReplenish(group, proxies, 10)
+	if buy != 0 || len(release) != 2 || release[0].ID != 4 || release[1].ID != 2 {
+		t.Errorf("buy=%d release=%v", buy, release)
+	}
+
+	// 手动录入（无服务商ID）或来自其他服务商的代理不释放
+	proxies = replenishProxies(0, 0)
+	proxies[0].ProviderID = ""
+	proxies[1].Source = "other"
+	if _, release := planReplenish(group, proxies, 0); len(release) != 0 {
+		t.Errorf("release=%v, want none", release)
+	}
+}
+
+func TestPlanReplenishDisabled(t *testing.T) {
+	group := &models.Groups{MaxOnline: 2, ReplenishProvider: "fake"}
+	if buy, release := planReplenish(group, replenishProxies(2, 2), 0); buy != 0 || release != nil {
+		t.Errorf("buy=%d release=%v, want no action without rules", buy, release)
+	}
+}
+
+func TestPurchaseDays(t *testing.T) {
+	cases := map[int]int{0: models.DefaultReplenishDays, -1: models.DefaultReplenishDays, 7: 7}
+	for days, want := range cases {
+		if got := (&models.Groups{ReplenishDays: days}).PurchaseDays(); got != want {
+			t.Errorf("ReplenishDays=%d: got %d, want %d", days, got, want)
+		}
+	}
+}
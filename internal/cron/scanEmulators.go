@@ -38,6 +38,23 @@ func (s *ScanEmulatorsTaskSvc) getEmulatorRepo() repo.EmulatorRepo {
 	return factory.EmulatorRepo(s.db)
 }
 
+// releasedIPs 统计每个 IP 释放的模拟器数，并记录冷却的释放者。同一 IP 有多个模拟器同时过期时
+// 取第一个，后面的不覆盖，与 SetCooldownTx 在冷却期内不改写释放者的语义一致
+func releasedIPs(emulators []*models.Emulator) (map[string]int, map[string]*models.Emulator) {
+	counts := make(map[string]int)
+	releasedBy := make(map[string]*models.Emulator)
+	for _, e := range emulators {
+		if e.IP == "" {
+			continue
+		}
+		counts[e.IP]++
+		if _, ok := releasedBy[e.IP]; !ok {
+			releasedBy[e.IP] = e
+		}
+	}
+	return counts, releasedBy
+}
+
 func (s *ScanEmulatorsTaskSvc) ScanAndDeleteExpiredEmulators() ([]*models.GroupReleaseResult, error) {
 	msgPrefix := "定时清扫模拟器失败"
 
@@ -56,15 +73,12 @@ func (s *ScanEmulatorsTaskSvc) ScanAndDeleteExpiredEmulators() ([]*models.GroupR
 
 	// 2. 分组统计
 	grouped := make(map[int64][]*models.Emulator)
-	ipReleaseMap := make(map[string]int)
 	groupIDSet := make(map[int64]struct{})
 	for _, e := range emulators {
 		grouped[e.GroupID] = append(grouped[e.GroupID], e)
 		groupIDSet[e.GroupID] = struct{}{}
-		if e.IP != "" {
-			ipReleaseMap[e.IP]++
-		}
 	}
+	ipReleaseMap, ipReleasedBy := releasedIPs(emulators)
 
 	// 3. 获取 group 元信息
 	var groupIDList []int64
@@ -85,19 +99,31 @@ func (s *ScanEmulatorsTaskSvc) ScanAndDeleteExpiredEmulators() ([]*models.GroupR
 		proxyRepo := factory.ProxyRepo(tx)
 		emulatorRepo := factory.EmulatorRepo(tx)
 
-		// 4.1 遍历 IP 执行递减
+		// 4.1 遍历 IP 执行递减，并按分组配置设置释放冷却
+		now := time.Now().Unix()
 		for ip, count := range ipReleaseMap {
 			if err := proxyRepo.DecrementInUseTx(tx, ip, count); err != nil {
 				logger.ErrorfWithTrace(s.ctx, "更新 IP [%s] 的使用数失败: %s", ip, err.Error())
 				return fmt.Errorf("更新 IP %s 使用数失败: %w", ip, err)
 			}
+			releasedBy := ipReleasedBy[ip]
+			if g, ok := groupsMap[releasedBy.GroupID]; ok {
+				if err := proxyRepo.SetCooldownTx(tx, ip, now, g.CooldownUntil(now), releasedBy.UUID); err != nil {
+					logger.ErrorfWithTrace(s.ctx, "设置 IP [%s] 冷却失败: %s", ip, err.Error())
+					return fmt.Errorf("设置 IP %s 冷却失败: %w", ip, err)
+				}
+			}
 		}
 
-		// 4.2 删除 emulator
+		// 4.2 删除 emulator，并结束其绑定历史
 		var uuids []string
 		for _, e := range emulators {
 			uuids = append(uuids, e.UUID)
 		}
+		if err := factory.BindingHistoryRepo(tx).CloseByUuids(uuids, models.BindReasonExpire, now); err != nil {
+			logger.ErrorfWithTrace(s.ctx, "记录解绑历史失败: %s", err.Error())
+			return err
+		}
 		if err := emulatorRepo.DeletesByUuidsTx(tx, uuids); err != nil {
 			logger.ErrorfWithTrace(s.ctx, "删除 Emulator 失败: %s", err.Error())
 			return err
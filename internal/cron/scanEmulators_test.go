@@ -0,0 +1,25 @@
+package cron
+
+import (
+	"testing"
+
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+// 同一 IP 的多个模拟器同时过期时，冷却释放者取第一个，不被后面的覆盖
+func TestReleasedIPs(t *testing.T) {
+	emulators := []*models.Emulator{
+		{UUID: "emu-a", IP: "10.0.0.1"},
+		{UUID: "emu-b", IP: "10.0.0.1"},
+		{UUID: "emu-c", IP: "10.0.0.2"},
+		{UUID: "emu-d"},
+	}
+	counts, releasedBy := releasedIPs(emulators)
+
+	if len(counts) != 2 || counts["10.0.0.1"] != 2 || counts["10.0.0.2"] != 1 {
+		t.Errorf("counts = %v", counts)
+	}
+	if releasedBy["10.0.0.1"].UUID != "emu-a" || releasedBy["10.0.0.2"].UUID != "emu-c" {
+		t.Errorf("releasedBy = %+v, %+v", releasedBy["10.0.0.1"], releasedBy["10.0.0.2"])
+	}
+}
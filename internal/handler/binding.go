@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxliu9403/ProxyHub/internal/common"
+	"github.com/maxliu9403/ProxyHub/internal/logic/binding"
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+type bindingController struct {
+	common.BaseController
+}
+
+func newBindingController(base common.BaseController) *bindingController {
+	return &bindingController{BaseController: base}
+}
+
+// GetList godoc
+// @Summary     查询模拟器与代理IP的绑定历史
+// @Description 按模拟器 uuid、代理 IP、分组、原因与时间范围查询绑定历史，时间范围返回与之有交集的绑定期，UnbindTime 为 0 表示仍在绑定中。
+// @Description 原因取值：bind | rotate | manual | release | expire
+// @Tags        绑定历史
+// @Security    AdminTokenAuth
+// @Accept      json
+// @Produce     json
+// @Param       params body models.GetBindingHistoryListParams false "查询参数"
+// @Success     200 {object} common.ResponseWithTotalCount{Data=[]models.BindingHistory}
+// @Failure     500 {object} common.Response
+// @Router      /api/binding/search [post]
+func (b *bindingController) GetList(c *gin.Context) {
+	var (
+		svc    binding.Svc
+		params models.GetBindingHistoryListParams
+	)
+
+	if !b.CheckParams(c, &params) {
+		return
+	}
+
+	params.Keyword = strings.TrimSpace(params.Keyword)
+	svc.Ctx = c
+
+	data, err := svc.GetList(params)
+	if err != nil || data == nil {
+		b.ResponseWithTotalCount(c, []models.BindingHistory{}, 0, err)
+		return
+	}
+
+	b.ResponseWithTotalCount(c, data.Data, data.Counts, nil)
+}
@@ -2,11 +2,13 @@ package handler
 
 import (
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/maxliu9403/ProxyHub/internal/common"
 	"github.com/maxliu9403/ProxyHub/internal/logic/emulator"
+	"github.com/maxliu9403/ProxyHub/internal/pkg/export"
 	"github.com/maxliu9403/ProxyHub/models"
 )
 
@@ -154,3 +156,33 @@ func (e *emulatorController) Update(c *gin.Context) {
 	err := svc.Update(params)
 	e.Response(c, nil, err)
 }
+
+// Export godoc
+// @Summary     导出模拟器
+// @Description 按与 /api/emulator/search 相同的条件流式导出模拟器，格式为 csv（默认）、json 或 ndjson，Limit 为 0 时导出全部。
+// @Tags        模拟器管理
+// @Security    AdminTokenAuth
+// @Accept      json
+// @Produce     text/csv,application/json,application/x-ndjson
+// @Param       params  body  emulator.ExportParams  false  "导出参数"
+// @Success     200     {file}    file
+// @Failure     500     {object}  common.Response
+// @Router      /api/emulator/export [post]
+func (m *emulatorController) Export(c *gin.Context) {
+	var (
+		svc    emulator.Svc
+		params emulator.ExportParams
+	)
+
+	if !m.CheckParams(c, &params) {
+		return
+	}
+	if params.Format == "" {
+		params.Format = export.FormatCSV
+	}
+
+	svc.Ctx = c
+	streamExport(c, &m.BaseController, "emulators", params.Format, func(w io.Writer) error {
+		return svc.Export(params, w)
+	})
+}
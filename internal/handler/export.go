@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxliu9403/ProxyHub/internal/common"
+	"github.com/maxliu9403/ProxyHub/internal/pkg/export"
+)
+
+// streamExport 以附件形式流式写出导出内容。
+// 出错时如果还没有内容写到客户端，改为返回 JSON 错误；已经开始写出时无法再改状态码，只能中断响应
+func streamExport(c *gin.Context, base *common.BaseController, name, format string, write func(w io.Writer) error) {
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+
+	err := write(c.Writer)
+	if err == nil {
+		return
+	}
+	if c.Writer.Written() {
+		c.Abort()
+		return
+	}
+	c.Writer.Header().Del("Content-Disposition")
+	c.Writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+	base.Response(c, nil, err)
+}
@@ -8,10 +8,12 @@ package handler
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/gin-gonic/gin"
 	"github.com/maxliu9403/ProxyHub/internal/common"
 	"github.com/maxliu9403/ProxyHub/internal/logic/proxy"
+	"github.com/maxliu9403/ProxyHub/internal/pkg/export"
 	"github.com/maxliu9403/ProxyHub/models"
 )
 
@@ -25,7 +27,7 @@ func newProxyController(base common.BaseController) *proxyController {
 
 // GetList godoc
 // @Summary     获取代理列表
-// @Description 支持分页与多条件查询
+// @Description 支持分页与多条件查询，Order 支持按 LatencyMs 等字段排序，如 "LatencyMs asc"
 // @Tags        代理管理
 // @Security    AdminTokenAuth
 // @Accept      json
@@ -59,13 +61,13 @@ func (m *proxyController) GetList(c *gin.Context) {
 
 // Detail godoc
 // @Summary     获取代理详情
-// @Description 通过 IP 获取代理信息（单个）
+// @Description 通过 IP 获取代理信息（单个），包含最近的健康检测延迟记录
 // @Tags        代理管理
 // @Security    AdminTokenAuth
 // @Accept      json
 // @Produce     json
 // @Param       ip   path     string  true  "代理 IP"
-// @Success     200  {object}  common.Response{Data=models.Proxy}
+// @Success     200  {object}  common.Response{Data=proxy.DetailResp}
 // @Failure     500  {object}  common.Response
 // @Router      /api/proxy/{ip} [get]
 func (m *proxyController) Detail(c *gin.Context) {
@@ -76,7 +78,7 @@ func (m *proxyController) Detail(c *gin.Context) {
 	}
 
 	svc := proxy.Svc{Ctx: c}
-	proxyItem, err := svc.GetByIP(ip)
+	proxyItem, err := svc.DetailByIP(ip)
 	m.Response(c, proxyItem, common.NewErrorCode(common.ErrGetDetail, err))
 }
 
@@ -108,6 +110,64 @@ func (m *proxyController) Create(c *gin.Context) {
 	m.Response(c, resp, common.NewErrorCode(common.ErrCreateProxy, err))
 }
 
+// Import godoc
+// @Summary     批量导入代理
+// @Description 导入纯文本（ip:port:user:pass、user:pass@ip:port 等，每行一个）、带表头的 CSV（可指定列映射）或 Clash/mihomo YAML 的 proxies 与 proxy-providers 段（支持 socks5/http/ss/trojan/vmess，http 与 file 类型的 provider 仅命令行导入支持，接口只解析 inline 类型）。
+// @Description 导入内容经规范化与去重后批量创建，返回逐行的 accepted | duplicate | malformed | unsupported 报告，DryRun 时只校验不写入。
+// @Tags        代理管理
+// @Security    AdminTokenAuth
+// @Accept      json
+// @Produce     json
+// @Param       params  body  proxy.ImportParams  true  "导入参数"
+// @Success     200     {object}  common.Response{Data=proxy.ImportResp}
+// @Failure     500     {object}  common.Response
+// @Router      /api/proxy/import [post]
+func (m *proxyController) Import(c *gin.Context) {
+	var (
+		svc    proxy.Svc
+		params proxy.ImportParams
+	)
+
+	if !m.CheckParams(c, &params) {
+		return
+	}
+
+	svc.Ctx = c
+	resp, err := svc.Import(params)
+	m.Response(c, resp, err)
+}
+
+// Export godoc
+// @Summary     导出代理
+// @Description 按与 /api/proxy/search 相同的条件流式导出代理，格式为 csv（默认）、json 或 ndjson，Limit 为 0 时导出全部。
+// @Description 密码及协议参数中的密码默认脱敏，WithPassword 为 true 时导出明文。
+// @Tags        代理管理
+// @Security    AdminTokenAuth
+// @Accept      json
+// @Produce     text/csv,application/json,application/x-ndjson
+// @Param       params  body  proxy.ExportParams  false  "导出参数"
+// @Success     200     {file}    file
+// @Failure     500     {object}  common.Response
+// @Router      /api/proxy/export [post]
+func (m *proxyController) Export(c *gin.Context) {
+	var (
+		svc    proxy.Svc
+		params proxy.ExportParams
+	)
+
+	if !m.CheckParams(c, &params) {
+		return
+	}
+	if params.Format == "" {
+		params.Format = export.FormatCSV
+	}
+
+	svc.Ctx = c
+	streamExport(c, &m.BaseController, "proxies", params.Format, func(w io.Writer) error {
+		return svc.Export(params, w)
+	})
+}
+
 // Update godoc
 // @Summary     更新代理
 // @Description 更新代理信息
@@ -17,6 +17,10 @@ import (
 const secret = "x-secret"
 
 func RegisterRouter(tra opentracing.Tracer, r *gin.RouterGroup) {
+	// 导出接口在挂载请求日志中间件之前单独建组：该中间件会缓存完整响应用于日志，
+	// 既无法流式输出，也会把明文密码写进日志
+	exportGroup := r.Group("/api", middleware.AdminAuthMiddleware(secret))
+
 	if tra != nil {
 		r.Use(middleware.GinInterceptorWithTrace(tra, true))
 	} else {
@@ -32,6 +36,8 @@ func RegisterRouter(tra opentracing.Tracer, r *gin.RouterGroup) {
 	tokenCtl := newTokenController(common.BaseController{})
 	emulatorCtl := newEmulatorController(common.BaseController{})
 	subscribeCtl := newSubscribeController(common.BaseController{})
+	templateCtl := newTemplateController(common.BaseController{})
+	bindingCtl := newBindingController(common.BaseController{})
 
 	// 管理员接口路由（带中间件）
 	adminGroup := r.Group("/api")
@@ -41,6 +47,9 @@ func RegisterRouter(tra opentracing.Tracer, r *gin.RouterGroup) {
 	registerTokenRouter(tokenCtl, adminGroup)
 	registerGroupRouter(groupCtl, adminGroup)
 	registerEmulatorRouter(emulatorCtl, adminGroup)
+	registerTemplateRouter(templateCtl, adminGroup)
+	registerBindingRouter(bindingCtl, adminGroup)
+	registerExportRouter(proxyCtl, emulatorCtl, exportGroup)
 }
 
 func registerGroupRouter(proxyGroup *groupController, group *gin.RouterGroup) {
@@ -56,6 +65,7 @@ func registerProxyRouter(proxy *proxyController, group *gin.RouterGroup) {
 	group.GET("/proxy/:ip", proxy.Detail)
 	group.DELETE("/proxy", proxy.Delete)
 	group.POST("/proxy", proxy.Create)
+	group.POST("/proxy/import", proxy.Import)
 	group.PUT("/proxy", proxy.Update)
 }
 
@@ -77,3 +87,25 @@ func registerEmulatorRouter(emulator *emulatorController, group *gin.RouterGroup
 func registerSubscribeRouter(subscribe *subscribeController, group *gin.RouterGroup) {
 	group.GET("/subscribe/:token/:uuid", subscribe.Get)
 }
+
+func registerTemplateRouter(template *templateController, group *gin.RouterGroup) {
+	group.POST("/template/search", template.GetList)
+	group.GET("/template/:id", template.Detail)
+	group.GET("/template/:id/versions", template.Versions)
+	group.DELETE("/template", template.Delete)
+	group.POST("/template", template.Create)
+	group.PUT("/template", template.Update)
+	group.POST("/template/rollback", template.Rollback)
+	group.POST("/template/preview", template.Preview)
+	group.POST("/template/bind", template.Bind)
+	group.DELETE("/template/bind", template.Unbind)
+}
+
+func registerBindingRouter(binding *bindingController, group *gin.RouterGroup) {
+	group.POST("/binding/search", binding.GetList)
+}
+
+func registerExportRouter(proxy *proxyController, emulator *emulatorController, group *gin.RouterGroup) {
+	group.POST("/proxy/export", proxy.Export)
+	group.POST("/emulator/export", emulator.Export)
+}
@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/maxliu9403/ProxyHub/internal/logic/token"
 
@@ -21,12 +24,17 @@ func newSubscribeController(base common.BaseController) *subscribeController {
 
 // Get godoc
 // @Summary     获取代理配置
-// @Description 通过 token 和 uuid 获取对应的 Clash 代理配置（YAML 格式）
+// @Description 通过 token 和 uuid 获取对应的代理配置，格式由 format 参数或 User-Agent 决定，默认 Clash YAML。
+// @Description 按分组轮换策略（always | sticky | on_demand）决定是否切换 IP（未绑定时自动绑定），传 rotate=1 时强制切换
 // @Tags        订阅管理
 // @Produce     plain
 // @Param       token   path     string  true  "授权 Token"
 // @Param       uuid    path     string  true  "模拟器 uuid"
-// @Success     200     {string}  string  "YAML 配置内容"
+// @Param       format  query    string  false "输出格式：clash | singbox | surge | shadowrocket | quanx | loon | xray | base64"
+// @Param       rotate  query    string  false "是否切换 IP：1 | true"
+// @Param       If-None-Match header string false "上次返回的 ETag，命中时返回 304"
+// @Success     200     {string}  string  "配置内容"
+// @Success     304     {string}  string  "配置未变化"
 // @Failure     400     {object} common.Response "参数错误"
 // @Failure     500     {object} common.Response "服务器内部错误"
 // @Router      /api/subscribe/{token}/{uuid} [get]
@@ -39,18 +47,50 @@ func (m *subscribeController) Get(c *gin.Context) {
 		return
 	}
 
+	format, err := subscribe.DetectFormat(c.Query("format"), c.GetHeader("User-Agent"))
+	if err != nil {
+		m.Response(c, nil, common.NewErrorCode(common.ErrInvalidParams, err))
+		return
+	}
+
 	tokenSvc := &token.Svc{Ctx: c} // 需要是指针，因为接口是由 *token.Svc 实现的
 	svc := subscribe.Svc{
 		Ctx:            c,
 		TokenValidator: tokenSvc,
 	}
-	clashCfg, err := svc.Subscribe(tokenParam, uuid)
+	result, err := svc.Subscribe(tokenParam, uuid, subscribe.SubscribeOptions{
+		Format:      format,
+		Rotate:      isTrue(c.Query("rotate")),
+		IfNoneMatch: c.GetHeader("If-None-Match"),
+	})
 	if err != nil {
+		// 逻辑层已明确错误码（如无符合地区要求的代理）时直接返回
+		var codeErr common.CodeWithErr
+		if errors.As(err, &codeErr) {
+			m.Response(c, nil, codeErr)
+			return
+		}
 		m.Response(c, nil, common.NewErrorCode(common.ErrGetSubscribe, err))
 		return
 	}
 
-	// 设置响应头并直接写入 YAML 配置内容
-	c.Header("Content-Type", "application/yaml")
-	c.String(http.StatusOK, clashCfg)
+	// 设置响应头
+	for k, v := range result.Headers {
+		c.Header(k, v)
+	}
+	c.Header("ETag", result.ETag)
+	if result.NotModified {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	// 直接写入配置内容
+	c.Header("Content-Type", format.ContentType())
+	c.String(http.StatusOK, result.Config)
+}
+
+// isTrue 解析布尔类型的查询参数
+func isTrue(v string) bool {
+	b, err := strconv.ParseBool(strings.TrimSpace(v))
+	return err == nil && b
 }
@@ -0,0 +1,297 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maxliu9403/ProxyHub/internal/common"
+	"github.com/maxliu9403/ProxyHub/internal/logic/template"
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+type templateController struct {
+	common.BaseController
+}
+
+func newTemplateController(base common.BaseController) *templateController {
+	return &templateController{BaseController: base}
+}
+
+// parseID 解析路径中的模板 ID
+func (m *templateController) parseID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		m.Response(c, nil, common.NewErrorCode(common.ErrInvalidParams, fmt.Errorf("无效的ID参数")))
+		return 0, false
+	}
+	return id, true
+}
+
+// GetList godoc
+// @Summary     获取模板列表
+// @Description 支持分页与多条件查询
+// @Tags        模板管理
+// @Security    AdminTokenAuth
+// @Accept      json
+// @Produce     json
+// @Param       params body models.GetTemplateListParams false "查询参数"
+// @Success     200 {object} common.ResponseWithTotalCount{Data=[]models.Template}
+// @Failure     500 {object} common.Response
+// @Router      /api/template/search [post]
+func (m *templateController) GetList(c *gin.Context) {
+	var (
+		svc    template.Svc
+		err    error
+		params models.GetTemplateListParams
+	)
+
+	if !m.CheckParams(c, &params) {
+		return
+	}
+	if params.Limit == 0 {
+		params.Limit = 10
+	}
+
+	params.Keyword = strings.TrimSpace(params.Keyword)
+
+	svc.Ctx = c
+	data, err := svc.GetList(params)
+	if data == nil || err != nil {
+		m.ResponseWithTotalCount(c, []models.Template{}, 0, common.NewErrorCode(common.ErrGetList, err))
+		return
+	}
+	m.ResponseWithTotalCount(c, data.Data, data.Counts, nil)
+}
+
+// Detail godoc
+// @Summary     获取模板详情
+// @Description 获取模板内容及绑定关系
+// @Tags        模板管理
+// @Security    AdminTokenAuth
+// @Produce     json
+// @Param       id   path     int  true  "模板ID"
+// @Success     200  {object}  common.Response{Data=template.TemplateDetailResp}
+// @Failure     500  {object}  common.Response
+// @Router      /api/template/{id} [get]
+func (m *templateController) Detail(c *gin.Context) {
+	id, ok := m.parseID(c)
+	if !ok {
+		return
+	}
+
+	svc := template.Svc{Ctx: c, ID: id}
+	resp, err := svc.Detail()
+	m.Response(c, resp, common.NewErrorCode(common.ErrGetDetail, err))
+}
+
+// Versions godoc
+// @Summary     获取模板历史版本
+// @Description 按版本号倒序返回模板的全部历史版本
+// @Tags        模板管理
+// @Security    AdminTokenAuth
+// @Produce     json
+// @Param       id   path     int  true  "模板ID"
+// @Success     200  {object}  common.Response{Data=[]models.TemplateVersion}
+// @Failure     500  {object}  common.Response
+// @Router      /api/template/{id}/versions [get]
+func (m *templateController) Versions(c *gin.Context) {
+	id, ok := m.parseID(c)
+	if !ok {
+		return
+	}
+
+	svc := template.Svc{Ctx: c, ID: id}
+	resp, err := svc.Versions()
+	m.Response(c, resp, common.NewErrorCode(common.ErrGetList, err))
+}
+
+// Create godoc
+// @Summary     创建模板
+// @Description 创建订阅模板，初始版本为 v1
+// @Tags        模板管理
+// @Security    AdminTokenAuth
+// @Accept      json
+// @Produce     json
+// @Param       params  body  template.CreateParams  true  "创建参数"
+// @Success     200     {object}  common.Response{Data=models.Template}
+// @Failure     500     {object}  common.Response
+// @Router      /api/template [post]
+func (m *templateController) Create(c *gin.Context) {
+	var (
+		svc    template.Svc
+		err    error
+		params template.CreateParams
+	)
+
+	if !m.CheckParams(c, &params) {
+		return
+	}
+
+	svc.Ctx = c
+	resp, err := svc.Create(params)
+	m.Response(c, resp, common.NewErrorCode(common.ErrCreateTemplate, err))
+}
+
+// Update godoc
+// @Summary     更新模板
+// @Description 更新模板，内容变更时版本号加一
+// @Tags        模板管理
+// @Security    AdminTokenAuth
+// @Accept      json
+// @Produce     json
+// @Param       params  body  template.UpdateParams  true  "更新参数"
+// @Success     200     {object}  common.Response
+// @Failure     500     {object}  common.Response
+// @Router      /api/template [put]
+func (m *templateController) Update(c *gin.Context) {
+	var (
+		svc    template.Svc
+		err    error
+		params template.UpdateParams
+	)
+
+	if !m.CheckParams(c, &params) {
+		return
+	}
+
+	svc.Ctx = c
+	err = svc.Update(params)
+	m.Response(c, nil, common.NewErrorCode(common.ErrUpdateTemplate, err))
+}
+
+// Delete godoc
+// @Summary     删除模板
+// @Description 删除模板及其绑定关系
+// @Tags        模板管理
+// @Security    AdminTokenAuth
+// @Accept      json
+// @Produce     json
+// @Param       params  body  template.DeleteParams  true  "删除请求参数"
+// @Success     200     {object}  common.Response
+// @Failure     500     {object}  common.Response
+// @Router      /api/template [delete]
+func (m *templateController) Delete(c *gin.Context) {
+	var (
+		svc    template.Svc
+		err    error
+		params template.DeleteParams
+	)
+
+	if !m.CheckParams(c, &params) {
+		return
+	}
+
+	svc.Ctx = c
+	err = svc.Delete(params)
+	m.Response(c, nil, common.NewErrorCode(common.ErrDeleteTemplate, err))
+}
+
+// Rollback godoc
+// @Summary     回滚模板
+// @Description 以指定历史版本的内容发布一个新版本
+// @Tags        模板管理
+// @Security    AdminTokenAuth
+// @Accept      json
+// @Produce     json
+// @Param       params  body  template.RollbackParams  true  "回滚参数"
+// @Success     200     {object}  common.Response{Data=models.Template}
+// @Failure     500     {object}  common.Response
+// @Router      /api/template/rollback [post]
+func (m *templateController) Rollback(c *gin.Context) {
+	var (
+		svc    template.Svc
+		err    error
+		params template.RollbackParams
+	)
+
+	if !m.CheckParams(c, &params) {
+		return
+	}
+
+	svc.Ctx = c
+	resp, err := svc.Rollback(params)
+	m.Response(c, resp, common.NewErrorCode(common.ErrRollbackTemplate, err))
+}
+
+// Bind godoc
+// @Summary     绑定模板
+// @Description 将模板绑定到分组，传入 EmulatorUUID 时为该模拟器单独覆盖
+// @Tags        模板管理
+// @Security    AdminTokenAuth
+// @Accept      json
+// @Produce     json
+// @Param       params  body  template.BindParams  true  "绑定参数"
+// @Success     200     {object}  common.Response
+// @Failure     500     {object}  common.Response
+// @Router      /api/template/bind [post]
+func (m *templateController) Bind(c *gin.Context) {
+	var (
+		svc    template.Svc
+		err    error
+		params template.BindParams
+	)
+
+	if !m.CheckParams(c, &params) {
+		return
+	}
+
+	svc.Ctx = c
+	err = svc.Bind(params)
+	m.Response(c, nil, common.NewErrorCode(common.ErrBindTemplate, err))
+}
+
+// Unbind godoc
+// @Summary     解绑模板
+// @Description 解除分组或模拟器在某格式下的模板绑定，解绑后回退到上一级模板
+// @Tags        模板管理
+// @Security    AdminTokenAuth
+// @Accept      json
+// @Produce     json
+// @Param       params  body  template.UnbindParams  true  "解绑参数"
+// @Success     200     {object}  common.Response
+// @Failure     500     {object}  common.Response
+// @Router      /api/template/bind [delete]
+func (m *templateController) Unbind(c *gin.Context) {
+	var (
+		svc    template.Svc
+		err    error
+		params template.UnbindParams
+	)
+
+	if !m.CheckParams(c, &params) {
+		return
+	}
+
+	svc.Ctx = c
+	err = svc.Unbind(params)
+	m.Response(c, nil, common.NewErrorCode(common.ErrBindTemplate, err))
+}
+
+// Preview godoc
+// @Summary     预览并校验模板
+// @Description 使用示例数据或分组/模拟器的真实代理渲染模板，并校验渲染结果的结构，不会修改绑定关系
+// @Tags        模板管理
+// @Security    AdminTokenAuth
+// @Accept      json
+// @Produce     json
+// @Param       params  body  template.PreviewParams  true  "预览参数"
+// @Success     200     {object}  common.Response{Data=template.PreviewResp}
+// @Failure     500     {object}  common.Response
+// @Router      /api/template/preview [post]
+func (m *templateController) Preview(c *gin.Context) {
+	var (
+		svc    template.Svc
+		err    error
+		params template.PreviewParams
+	)
+
+	if !m.CheckParams(c, &params) {
+		return
+	}
+
+	svc.Ctx = c
+	resp, err := svc.Preview(params)
+	m.Response(c, resp, common.NewErrorCode(common.ErrPreviewTemplate, err))
+}
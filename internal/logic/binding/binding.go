@@ -0,0 +1,41 @@
+package binding
+
+import (
+	"context"
+
+	"github.com/maxliu9403/ProxyHub/internal/common"
+	"github.com/maxliu9403/ProxyHub/models"
+	"github.com/maxliu9403/ProxyHub/models/factory"
+	"github.com/maxliu9403/ProxyHub/models/repo"
+	"github.com/maxliu9403/common/gormdb"
+	"github.com/maxliu9403/common/logger"
+	"gorm.io/gorm"
+)
+
+type Svc struct {
+	Ctx context.Context
+	DB  *gorm.DB
+}
+
+func (s *Svc) getRepo() repo.BindingHistoryRepo {
+	s.DB = gormdb.Cli(s.Ctx)
+	return factory.BindingHistoryRepo(s.DB)
+}
+
+func (s *Svc) GetList(q models.GetBindingHistoryListParams) (data *common.ListData, err error) {
+	data = &common.ListData{}
+
+	crud := s.getRepo()
+	table := &models.BindingHistory{}
+	list := make([]models.BindingHistory, 0)
+	total, err := crud.GetList(q, table, &list)
+	if err != nil {
+		logger.ErrorfWithTrace(s.Ctx, "query binding history failed: %s", err.Error())
+		return data, common.NewErrorCode(common.ErrGetList, err)
+	}
+
+	data.Counts = total
+	data.Data = list
+
+	return data, err
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/maxliu9403/ProxyHub/internal/common"
 	"github.com/maxliu9403/ProxyHub/internal/logic/group"
@@ -87,27 +89,48 @@ func (s *Svc) Delete(params DeleteParams) (resp *DeleteResp, err error) {
 
 	err = gormdb.Cli(s.Ctx).Transaction(func(tx *gorm.DB) error {
 		// 查询要删除的 emulator 的 IP（排除空 IP）
-		var ipList []string
+		var emulators []*models.Emulator
 		if err := tx.Model(&models.Emulator{}).
+			Select("uuid", "ip", "group_id").
 			Where("uuid IN ?", params.Uuids).
 			Where("ip != ''").
-			Pluck("ip", &ipList).Error; err != nil {
+			Find(&emulators).Error; err != nil {
 			logger.ErrorfWithTrace(s.Ctx, "query emulator IPs failed: %s", err.Error())
 			return common.NewErrorCode(common.ErrDeleteEmulator, fmt.Errorf("查询模拟器 IP 失败: %w", err))
 		}
 
-		// 释放 IP 计数（去重 + 计数）
+		// 释放 IP 计数（去重 + 计数），并记录释放者用于冷却；同一 IP 的多个模拟器一起删除时取第一个，
+		// 与 SetCooldownTx 一致，后释放的不覆盖先释放的
 		releaseIPMap := make(map[string]int)
-		for _, ip := range ipList {
-			releaseIPMap[ip] += 1
+		releasedBy := make(map[string]*models.Emulator)
+		groupIDs := make([]int64, 0)
+		for _, e := range emulators {
+			releaseIPMap[e.IP] += 1
+			if _, ok := releasedBy[e.IP]; !ok {
+				releasedBy[e.IP] = e
+			}
+			groupIDs = append(groupIDs, e.GroupID)
+		}
+
+		groupsMap, err := factory.GroupsRepo(tx).GetByIDs(groupIDs)
+		if err != nil {
+			logger.ErrorfWithTrace(s.Ctx, "query groups failed: %s", err.Error())
+			return common.NewErrorCode(common.ErrDeleteEmulator, fmt.Errorf("查询分组失败: %w", err))
 		}
 
 		// 批量递减 inuse_count
+		now := time.Now().Unix()
 		for ip, count := range releaseIPMap {
 			if err := proxyRepo.DecrementInUseTx(tx, ip, count); err != nil {
 				logger.ErrorfWithTrace(s.Ctx, "decrement inuse_count for IP [%s] failed: %s", ip, err.Error())
 				return common.NewErrorCode(common.ErrDeleteEmulator, fmt.Errorf("更新 proxy 使用数失败 (IP=%s): %w", ip, err))
 			}
+			if g, ok := groupsMap[releasedBy[ip].GroupID]; ok {
+				if err := proxyRepo.SetCooldownTx(tx, ip, now, g.CooldownUntil(now), releasedBy[ip].UUID); err != nil {
+					logger.ErrorfWithTrace(s.Ctx, "set cooldown for IP [%s] failed: %s", ip, err.Error())
+					return common.NewErrorCode(common.ErrDeleteEmulator, fmt.Errorf("设置 IP 冷却失败 (IP=%s): %w", ip, err))
+				}
+			}
 			// 添加返回详情
 			resp.ReleaseIPsDetail = append(resp.ReleaseIPsDetail, &ReleaseIPDetail{
 				IP:           ip,
@@ -115,6 +138,18 @@ func (s *Svc) Delete(params DeleteParams) (resp *DeleteResp, err error) {
 			})
 		}
 
+		// 结束绑定历史
+		if err := factory.BindingHistoryRepo(tx).CloseByUuids(params.Uuids, models.BindReasonRelease, now); err != nil {
+			logger.ErrorfWithTrace(s.Ctx, "close binding history failed: %s", err.Error())
+			return common.NewErrorCode(common.ErrDeleteEmulator, fmt.Errorf("记录解绑历史失败: %w", err))
+		}
+
+		// 删除模拟器级模板绑定
+		if err := tx.Unscoped().Where("emulator_uuid IN ?", params.Uuids).Delete(&models.TemplateBinding{}).Error; err != nil {
+			logger.ErrorfWithTrace(s.Ctx, "delete template bindings failed: %s", err.Error())
+			return common.NewErrorCode(common.ErrDeleteEmulator, fmt.Errorf("删除模板绑定失败: %w", err))
+		}
+
 		// 删除模拟器
 		if err := tx.Where("uuid IN ?", params.Uuids).Delete(&models.Emulator{}).Error; err != nil {
 			logger.ErrorfWithTrace(s.Ctx, "delete emulator failed: %s", err.Error())
@@ -132,6 +167,8 @@ type CreateParams struct {
 	BrowserID string `json:"BrowserID" binding:"required,gt=0"` // 窗口ID
 	UUID      string `json:"Uuid" binding:"required"`           // 模拟器uuid
 	GroupID   int64  `json:"GroupID" binding:"required"`        // 组ID
+	Country   string `json:"Country" binding:"omitempty,len=2"` // 要求的出口国家ISO代码，如 DE
+	Region    string `json:"Region" binding:"max=128"`          // 要求的出口一级行政区，如 Bavaria
 }
 
 type CreateBatchParams struct {
@@ -143,6 +180,8 @@ func (p CreateParams) ToModel() *models.Emulator {
 		UUID:      p.UUID,
 		BrowserID: p.BrowserID,
 		GroupID:   p.GroupID,
+		Country:   strings.ToUpper(p.Country),
+		Region:    p.Region,
 	}
 }
 
@@ -246,6 +285,8 @@ type UpdateParams struct {
 	UUID    string  `json:"UUID" binding:"required"`
 	IP      *string `json:"IP,omitempty" binding:"omitempty,ip"`
 	GroupID *int64  `json:"GroupID,omitempty"  binding:"omitempty,gt=0"`
+	Country *string `json:"Country,omitempty" binding:"omitempty,len=2|len=0"` // 要求的出口国家ISO代码，传空字符串取消限制
+	Region  *string `json:"Region,omitempty" binding:"omitempty,max=128"`      // 要求的出口一级行政区，传空字符串取消限制
 }
 
 func (s *Svc) Update(params UpdateParams) error {
@@ -263,6 +304,14 @@ func (s *Svc) Update(params UpdateParams) error {
 
 	if params.IP != nil {
 		updateFields["ip"] = *params.IP
+		updateFields["bound_at"] = time.Now().Unix()
+	}
+
+	if params.Country != nil {
+		updateFields["country"] = strings.ToUpper(*params.Country)
+	}
+	if params.Region != nil {
+		updateFields["region"] = *params.Region
 	}
 
 	if params.GroupID != nil {
@@ -277,7 +326,37 @@ func (s *Svc) Update(params UpdateParams) error {
 		updateFields["group_id"] = *params.GroupID
 	}
 
-	err = s.getRepo().Update(params.UUID, updateFields)
+	// IP、分组都未变化时只更新字段；IP 变化时同时记录手动解绑/绑定历史，
+	// 分组变化时模拟器级模板绑定随模拟器迁移到新分组
+	ipChanged := params.IP != nil && *params.IP != emulator.IP
+	groupChanged := params.GroupID != nil && *params.GroupID != emulator.GroupID
+	if !ipChanged && !groupChanged {
+		err = s.getRepo().Update(params.UUID, updateFields)
+		if err != nil {
+			logger.ErrorfWithTrace(s.Ctx, "update emulator failed: %s", err.Error())
+			return common.NewErrorCode(common.ErrUpdateGroup, err)
+		}
+		return nil
+	}
+
+	groupID := emulator.GroupID
+	if params.GroupID != nil {
+		groupID = *params.GroupID
+	}
+	err = gormdb.Cli(s.Ctx).Transaction(func(tx *gorm.DB) error {
+		if err := factory.EmulatorRepo(tx).Update(params.UUID, updateFields); err != nil {
+			return err
+		}
+		if groupChanged {
+			if err := moveTemplateBindings(tx, params.UUID, groupID); err != nil {
+				return fmt.Errorf("迁移模板绑定失败: %w", err)
+			}
+		}
+		if !ipChanged {
+			return nil
+		}
+		return recordManualBinding(tx, &emulator, *params.IP, groupID, updateFields["bound_at"].(int64))
+	})
 	if err != nil {
 		logger.ErrorfWithTrace(s.Ctx, "update emulator failed: %s", err.Error())
 		return common.NewErrorCode(common.ErrUpdateGroup, err)
@@ -285,3 +364,36 @@ func (s *Svc) Update(params UpdateParams) error {
 
 	return nil
 }
+
+// moveTemplateBindings 将模拟器级模板绑定迁移到新分组，先清理新分组下残留的同一模拟器绑定以免唯一索引冲突
+func moveTemplateBindings(tx *gorm.DB, uuid string, groupID int64) error {
+	if err := tx.Unscoped().
+		Where("emulator_uuid = ? AND group_id = ?", uuid, groupID).
+		Delete(&models.TemplateBinding{}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.TemplateBinding{}).
+		Where("emulator_uuid = ?", uuid).
+		Update("group_id", groupID).Error
+}
+
+// recordManualBinding 记录手动指定 IP 产生的解绑与绑定历史，新 IP 为空时只解绑
+func recordManualBinding(tx *gorm.DB, emulator *models.Emulator, ip string, groupID int64, now int64) error {
+	historyRepo := factory.BindingHistoryRepo(tx)
+	if emulator.IP != "" {
+		if err := historyRepo.CloseByUuids([]string{emulator.UUID}, models.BindReasonManual, now); err != nil {
+			return err
+		}
+	}
+
+	if ip == "" {
+		return nil
+	}
+	return historyRepo.Create(&models.BindingHistory{
+		EmulatorUUID: emulator.UUID,
+		GroupID:      groupID,
+		IP:           ip,
+		BindReason:   models.BindReasonManual,
+		BindTime:     now,
+	})
+}
@@ -0,0 +1,35 @@
+package emulator
+
+import (
+	"io"
+
+	"github.com/maxliu9403/ProxyHub/internal/common"
+	"github.com/maxliu9403/ProxyHub/internal/pkg/export"
+	"github.com/maxliu9403/ProxyHub/models"
+	"github.com/maxliu9403/common/logger"
+)
+
+type ExportParams struct {
+	models.GetEmulatorListParams        // 与 /api/emulator/search 相同的过滤条件，Limit 为 0 时导出全部
+	Format                       string `json:"Format,omitempty" binding:"omitempty,oneof=csv json ndjson"` // 导出格式，默认 csv
+}
+
+// Export 按查询条件逐行读取模拟器并写出，不把整张表加载到内存
+func (s *Svc) Export(params ExportParams, w io.Writer) error {
+	enc, err := export.NewEncoder(params.Format, w, export.Columns(models.Emulator{}))
+	if err != nil {
+		return common.NewErrorCode(common.ErrInvalidParams, err)
+	}
+
+	err = s.getRepo().Iterate(params.GetEmulatorListParams, func(e *models.Emulator) error {
+		return enc.Encode(e)
+	})
+	if err == nil {
+		err = enc.Close()
+	}
+	if err != nil {
+		logger.ErrorfWithTrace(s.Ctx, "export emulators failed: %s", err.Error())
+		return common.NewErrorCode(common.ErrExport, err)
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/maxliu9403/ProxyHub/internal/common"
 	"github.com/maxliu9403/ProxyHub/internal/logic"
+	"github.com/maxliu9403/ProxyHub/internal/pkg/provider"
 	"github.com/maxliu9403/ProxyHub/models"
 	"github.com/maxliu9403/ProxyHub/models/factory"
 	"github.com/maxliu9403/ProxyHub/models/repo"
@@ -183,6 +184,12 @@ func (s *Svc) Delete(params DeleteParams) error {
 			return common.NewErrorCode(common.ErrDeleteGroup, fmt.Errorf("删除Token失败: %w", err))
 		}
 
+		// 删除模板绑定
+		if err := tx.Unscoped().Where("group_id IN ?", groupIDs).Delete(&models.TemplateBinding{}).Error; err != nil {
+			logger.ErrorfWithTrace(s.Ctx, "delete template bindings failed: %s", err.Error())
+			return common.NewErrorCode(common.ErrDeleteGroup, fmt.Errorf("删除模板绑定失败: %w", err))
+		}
+
 		// 删除 group 本身
 		if err := tx.Where("id IN ?", groupIDs).Delete(&models.Groups{}).Error; err != nil {
 			logger.ErrorfWithTrace(s.Ctx, "delete groups failed: %s", err.Error())
@@ -194,20 +201,114 @@ func (s *Svc) Delete(params DeleteParams) error {
 }
 
 type CreateParams struct {
-	Name        string `json:"Name" binding:"required"`           // 组名，必须唯一
-	MaxOnline   int    `json:"MaxOnline" binding:"required,gt=0"` // 该分组内的IP最大同时在线模拟器数，必须大于0
-	Description string `json:"Description"`                       // 描述
+	Name        string `json:"Name" binding:"required"`                                // 组名，必须唯一
+	MaxOnline   int    `json:"MaxOnline" binding:"required,gt=0"`                      // 该分组内的IP最大同时在线模拟器数，必须大于0
+	Description string `json:"Description"`                                            // 描述
+	BackupCount int    `json:"BackupCount" binding:"gte=0"`                            // 订阅配置中附带的备用代理数
+	BackupType  string `json:"BackupType" binding:"omitempty,oneof=fallback url-test"` // 备用代理组类型，默认 fallback
+
+	RotationPolicy string `json:"RotationPolicy" binding:"omitempty,oneof=always sticky on_demand"` // IP轮换策略，默认 on_demand
+	StickyTTL      int64  `json:"StickyTTL" binding:"required_if=RotationPolicy sticky,gte=0"`      // sticky 策略下IP保持时长，单位秒
+
+	ReleaseCooldown int64 `json:"ReleaseCooldown" binding:"gte=0"` // IP释放后的冷却时长，单位秒，0表示不冷却
+
+	HistoryPolicy string `json:"HistoryPolicy" binding:"omitempty,oneof=none avoid affinity"`                                    // 历史IP策略，默认 none
+	HistoryDays   int    `json:"HistoryDays" binding:"required_if=HistoryPolicy avoid,required_if=HistoryPolicy affinity,gte=0"` // 历史IP策略参考的天数
+
+	ReplenishProvider   string `json:"ReplenishProvider" binding:"max=64"`        // 自动补充代理的服务商来源类型，为空不启用
+	TargetUtilization   int    `json:"TargetUtilization" binding:"gte=0,lte=100"` // 目标使用率百分比
+	MinFreeSlots        int    `json:"MinFreeSlots" binding:"gte=0"`              // 最少空闲名额数
+	ReplenishDays       int    `json:"ReplenishDays" binding:"gte=0"`             // 补充代理的购买时长，单位天，默认 30
+	ReplenishMaxProxies int    `json:"ReplenishMaxProxies" binding:"gte=0"`       // 自动补充后分组代理数上限，0表示不限制
+
+	SelectStrategy string `json:"SelectStrategy" binding:"omitempty,oneof=least_used weighted_random round_robin consistent_hash low_latency"` // 代理选择策略，默认 least_used
+
+	ProfileUpdateInterval int    `json:"ProfileUpdateInterval" binding:"gte=0"`  // 客户端自动更新订阅间隔，单位小时
+	ProfileFilename       string `json:"ProfileFilename" binding:"max=128"`      // 订阅文件名
+	SubscriptionUserinfo  string `json:"SubscriptionUserinfo" binding:"max=255"` // Subscription-Userinfo 响应头内容
+}
+
+// checkRotation 结合分组当前配置校验更新后的轮换策略，sticky 策略必须设置保持时长
+func (s *Svc) checkRotation(params UpdateParams) error {
+	g := &models.Groups{}
+	if err := s.getRepo().GetByID(g, params.ID); err != nil {
+		return err
+	}
+	if params.RotationPolicy != nil {
+		g.RotationPolicy = *params.RotationPolicy
+	}
+	if params.StickyTTL != nil {
+		g.StickyTTL = *params.StickyTTL
+	}
+	if g.RotationPolicy == models.RotationSticky && g.StickyTTL <= 0 {
+		return errors.New("sticky 策略需要设置大于 0 的 StickyTTL")
+	}
+	return nil
+}
+
+// checkReplenishProvider 自动补充的服务商必须是已注册的来源类型，为空表示不启用
+func checkReplenishProvider(source string) error {
+	if source == "" {
+		return nil
+	}
+	sources := provider.Sources()
+	for _, s := range sources {
+		if s == source {
+			return nil
+		}
+	}
+	return fmt.Errorf("未注册的服务商 %s，可选: %s", source, strings.Join(sources, ", "))
 }
 
 type CreateGroupBatchParams struct {
-	Groups []CreateParams `json:"Groups" binding:"required"` // 分组列表，不能为空
+	Groups []CreateParams `json:"Groups" binding:"required,dive"` // 分组列表，不能为空，逐个校验分组参数
 }
 
 func (p CreateParams) ToModel() *models.Groups {
+	backupType := p.BackupType
+	if backupType == "" {
+		backupType = models.BackupTypeFallback
+	}
+	selectStrategy := p.SelectStrategy
+	if selectStrategy == "" {
+		selectStrategy = models.SelectLeastUsed
+	}
+	rotationPolicy := p.RotationPolicy
+	if rotationPolicy == "" {
+		rotationPolicy = models.RotationOnDemand
+	}
+	historyPolicy := p.HistoryPolicy
+	if historyPolicy == "" {
+		historyPolicy = models.HistoryNone
+	}
+	replenishDays := p.ReplenishDays
+	if replenishDays == 0 {
+		replenishDays = models.DefaultReplenishDays
+	}
 	return &models.Groups{
-		Name:        p.Name,
-		MaxOnline:   p.MaxOnline,
-		Description: p.Description,
+		Name:           p.Name,
+		MaxOnline:      p.MaxOnline,
+		Description:    p.Description,
+		BackupCount:    p.BackupCount,
+		BackupType:     backupType,
+		RotationPolicy: rotationPolicy,
+		StickyTTL:      p.StickyTTL,
+		SelectStrategy: selectStrategy,
+
+		ReleaseCooldown: p.ReleaseCooldown,
+
+		HistoryPolicy: historyPolicy,
+		HistoryDays:   p.HistoryDays,
+
+		ReplenishProvider:   p.ReplenishProvider,
+		TargetUtilization:   p.TargetUtilization,
+		MinFreeSlots:        p.MinFreeSlots,
+		ReplenishDays:       replenishDays,
+		ReplenishMaxProxies: p.ReplenishMaxProxies,
+
+		ProfileUpdateInterval: p.ProfileUpdateInterval,
+		ProfileFilename:       p.ProfileFilename,
+		SubscriptionUserinfo:  p.SubscriptionUserinfo,
 	}
 }
 
@@ -229,9 +330,9 @@ func (s *Svc) CreateBatch(params CreateGroupBatchParams) (*CreateBatchResp, erro
 	createdList := make([]CreatedGroupInfo, 0)
 	paramMap := make(map[string]CreateParams)
 
-	// 预处理：过滤非法分组（name为空）
+	// 预处理：过滤非法分组（name为空、自动补充的服务商未注册）
 	for _, p := range params.Groups {
-		if p.Name == "" {
+		if p.Name == "" || checkReplenishProvider(p.ReplenishProvider) != nil {
 			invalidGroup = append(invalidGroup, p)
 			continue
 		}
@@ -327,10 +428,32 @@ func (s *Svc) CreateBatch(params CreateGroupBatchParams) (*CreateBatchResp, erro
 
 type UpdateParams struct {
 	common.Test
-	ID          int64   `json:"ID" binding:"required"`                        // 分组 ID，必填
-	Name        *string `json:"Name,omitempty"`                               // 组名
-	MaxOnline   *int    `json:"MaxOnline,omitempty" binding:"omitempty,gt=0"` // 该分组内的IP最大同时在线模拟器数，必须大于0
-	Description *string `json:"Description,omitempty"`                        // 描述
+	ID          int64   `json:"ID" binding:"required"`                                            // 分组 ID，必填
+	Name        *string `json:"Name,omitempty"`                                                   // 组名
+	MaxOnline   *int    `json:"MaxOnline,omitempty" binding:"omitempty,gt=0"`                     // 该分组内的IP最大同时在线模拟器数，必须大于0
+	Description *string `json:"Description,omitempty"`                                            // 描述
+	BackupCount *int    `json:"BackupCount,omitempty" binding:"omitempty,gte=0"`                  // 备用代理数
+	BackupType  *string `json:"BackupType,omitempty" binding:"omitempty,oneof=fallback url-test"` // 备用代理组类型
+
+	RotationPolicy *string `json:"RotationPolicy,omitempty" binding:"omitempty,oneof=always sticky on_demand"` // IP轮换策略
+	StickyTTL      *int64  `json:"StickyTTL,omitempty" binding:"omitempty,gte=0"`                              // sticky 策略下IP保持时长，单位秒
+
+	ReleaseCooldown *int64 `json:"ReleaseCooldown,omitempty" binding:"omitempty,gte=0"` // IP释放后的冷却时长，单位秒
+
+	HistoryPolicy *string `json:"HistoryPolicy,omitempty" binding:"omitempty,oneof=none avoid affinity"` // 历史IP策略
+	HistoryDays   *int    `json:"HistoryDays,omitempty" binding:"omitempty,gte=0"`                       // 历史IP策略参考的天数
+
+	ReplenishProvider   *string `json:"ReplenishProvider,omitempty" binding:"omitempty,max=64"`        // 自动补充代理的服务商来源类型，传空字符串关闭
+	TargetUtilization   *int    `json:"TargetUtilization,omitempty" binding:"omitempty,gte=0,lte=100"` // 目标使用率百分比
+	MinFreeSlots        *int    `json:"MinFreeSlots,omitempty" binding:"omitempty,gte=0"`              // 最少空闲名额数
+	ReplenishDays       *int    `json:"ReplenishDays,omitempty" binding:"omitempty,gte=0"`             // 补充代理的购买时长，单位天，传 0 恢复默认 30
+	ReplenishMaxProxies *int    `json:"ReplenishMaxProxies,omitempty" binding:"omitempty,gte=0"`       // 自动补充后分组代理数上限
+
+	SelectStrategy *string `json:"SelectStrategy,omitempty" binding:"omitempty,oneof=least_used weighted_random round_robin consistent_hash low_latency"` // 代理选择策略
+
+	ProfileUpdateInterval *int    `json:"ProfileUpdateInterval,omitempty" binding:"omitempty,gte=0"`  // 客户端自动更新订阅间隔，单位小时
+	ProfileFilename       *string `json:"ProfileFilename,omitempty" binding:"omitempty,max=128"`      // 订阅文件名
+	SubscriptionUserinfo  *string `json:"SubscriptionUserinfo,omitempty" binding:"omitempty,max=255"` // Subscription-Userinfo 响应头内容
 }
 
 func (s *Svc) Update(params UpdateParams) error {
@@ -344,6 +467,66 @@ func (s *Svc) Update(params UpdateParams) error {
 	if params.MaxOnline != nil {
 		updateFields["max_online"] = *params.MaxOnline
 	}
+	if params.BackupCount != nil {
+		updateFields["backup_count"] = *params.BackupCount
+	}
+	if params.BackupType != nil {
+		updateFields["backup_type"] = *params.BackupType
+	}
+	if params.RotationPolicy != nil || params.StickyTTL != nil {
+		if err := s.checkRotation(params); err != nil {
+			return common.NewErrorCode(common.ErrUpdateGroup, err)
+		}
+	}
+	if params.RotationPolicy != nil {
+		updateFields["rotation_policy"] = *params.RotationPolicy
+	}
+	if params.StickyTTL != nil {
+		updateFields["sticky_ttl"] = *params.StickyTTL
+	}
+	if params.ReleaseCooldown != nil {
+		updateFields["release_cooldown"] = *params.ReleaseCooldown
+	}
+	if params.HistoryPolicy != nil {
+		updateFields["history_policy"] = *params.HistoryPolicy
+	}
+	if params.HistoryDays != nil {
+		updateFields["history_days"] = *params.HistoryDays
+	}
+	if params.ReplenishProvider != nil {
+		if err := checkReplenishProvider(*params.ReplenishProvider); err != nil {
+			return common.NewErrorCode(common.ErrUpdateGroup, err)
+		}
+		updateFields["replenish_provider"] = *params.ReplenishProvider
+	}
+	if params.TargetUtilization != nil {
+		updateFields["target_utilization"] = *params.TargetUtilization
+	}
+	if params.MinFreeSlots != nil {
+		updateFields["min_free_slots"] = *params.MinFreeSlots
+	}
+	if params.ReplenishDays != nil {
+		days := *params.ReplenishDays
+		if days == 0 {
+			days = models.DefaultReplenishDays
+		}
+		updateFields["replenish_days"] = days
+	}
+	if params.ReplenishMaxProxies != nil {
+		updateFields["replenish_max_proxies"] = *params.ReplenishMaxProxies
+	}
+	if params.SelectStrategy != nil {
+		updateFields["select_strategy"] = *params.SelectStrategy
+	}
+	if params.ProfileUpdateInterval != nil {
+		updateFields["profile_update_interval"] = *params.ProfileUpdateInterval
+	}
+	if params.ProfileFilename != nil {
+		updateFields["profile_filename"] = *params.ProfileFilename
+	}
+	if params.SubscriptionUserinfo != nil {
+		updateFields["subscription_userinfo"] = *params.SubscriptionUserinfo
+	}
 
 	err := s.getRepo().Update(params.ID, updateFields)
 	if err != nil {
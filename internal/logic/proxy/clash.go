@@ -0,0 +1,205 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/maxliu9403/ProxyHub/models"
+	"gopkg.in/yaml.v3"
+)
+
+// proxy-provider 类型
+const (
+	ProviderInline = "inline" // 代理直接写在 payload 中
+	ProviderHTTP   = "http"   // 从 url 下载
+	ProviderFile   = "file"   // 读取本地 path
+)
+
+// ProviderLoader 读取 http/file 类型 proxy-provider 的内容，location 为 url 或 path
+type ProviderLoader func(kind, location string) ([]byte, error)
+
+// clashStandardKeys 直接映射到代理字段的 Clash 配置项，其余配置项原样保存到 Extra
+var clashStandardKeys = map[string]bool{
+	"name": true, "type": true, "server": true, "port": true, "username": true, "password": true,
+}
+
+// clashProvider Clash 配置中的单个 proxy-provider
+type clashProvider struct {
+	Type    string    `yaml:"type"`
+	URL     string    `yaml:"url"`
+	Path    string    `yaml:"path"`
+	Payload yaml.Node `yaml:"payload"`
+}
+
+// parseClash 解析 Clash/mihomo YAML 的 proxies 段和 proxy-providers 段，
+// http/file 类型的 provider 通过 load 读取，内容须为带 proxies 段的 YAML
+func parseClash(content string, load ProviderLoader) ([]importEntry, error) {
+	root, err := clashRoot([]byte(content))
+	if err != nil {
+		return nil, err
+	}
+	proxies, providers := clashSection(root, "proxies"), clashSection(root, "proxy-providers")
+	if proxies == nil && providers == nil {
+		return nil, errors.New("YAML 中没有 proxies 或 proxy-providers")
+	}
+
+	entries := make([]importEntry, 0)
+	if proxies != nil {
+		if proxies.Kind != yaml.SequenceNode {
+			return nil, errors.New("proxies 应为列表")
+		}
+		entries = append(entries, parseClashProxies(proxies, "")...)
+	}
+	if providers != nil {
+		if providers.Kind != yaml.MappingNode {
+			return nil, errors.New("proxy-providers 应为映射")
+		}
+		for i := 0; i+1 < len(providers.Content); i += 2 {
+			name, node := providers.Content[i].Value, providers.Content[i+1]
+			entries = append(entries, parseClashProvider(name, node, load)...)
+		}
+	}
+	return entries, nil
+}
+
+// parseClashProvider 解析单个 proxy-provider，读取或解析失败时整体记为一条错误
+func parseClashProvider(name string, node *yaml.Node, load ProviderLoader) []importEntry {
+	fail := func(err error) []importEntry {
+		return []importEntry{{line: node.Line, raw: name, provider: name, err: err}}
+	}
+
+	var p clashProvider
+	if err := node.Decode(&p); err != nil {
+		return fail(err)
+	}
+
+	if p.Type == ProviderInline {
+		if p.Payload.Kind != yaml.SequenceNode {
+			return fail(errors.New("inline 类型的 proxy-provider 缺少 payload 列表"))
+		}
+		return parseClashProxies(&p.Payload, name)
+	}
+
+	var location string
+	switch p.Type {
+	case ProviderHTTP:
+		location = p.URL
+	case ProviderFile:
+		location = p.Path
+	default:
+		return fail(fmt.Errorf("不支持的 proxy-provider 类型 %s", p.Type))
+	}
+	if location == "" {
+		return fail(fmt.Errorf("%s 类型的 proxy-provider 缺少 url 或 path", p.Type))
+	}
+	if load == nil {
+		return fail(fmt.Errorf("%s 类型的 proxy-provider 请通过命令行导入", p.Type))
+	}
+
+	data, err := load(p.Type, location)
+	if err != nil {
+		return fail(fmt.Errorf("读取 proxy-provider 失败: %w", err))
+	}
+	root, err := clashRoot(data)
+	if err != nil {
+		return fail(err)
+	}
+	proxies := clashSection(root, "proxies")
+	if proxies == nil || proxies.Kind != yaml.SequenceNode {
+		return fail(errors.New("proxy-provider 内容中没有 proxies 列表"))
+	}
+	return parseClashProxies(proxies, name)
+}
+
+// parseClashProxies 逐个解析代理列表，provider 为所属 proxy-provider 名称
+func parseClashProxies(proxies *yaml.Node, provider string) []importEntry {
+	entries := make([]importEntry, 0, len(proxies.Content))
+	for _, node := range proxies.Content {
+		entry := importEntry{line: node.Line, provider: provider}
+		fields := make(map[string]interface{})
+		if err := node.Decode(&fields); err != nil {
+			entry.err = err
+		} else {
+			entry.raw = fmt.Sprint(fields["name"])
+			entry.params, entry.err = clashProxyParams(fields)
+		}
+		if entry.err == nil {
+			entry.err = normalizeParams(&entry.params)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// clashProxyParams 将单个 Clash 代理映射为创建参数。
+// socks5/http 使用 username/password；ss、trojan 的 password 与 vmess 的 uuid 存入 Password，
+// cipher、sni、network 等协议参数以 JSON 保存到 Extra
+func clashProxyParams(fields map[string]interface{}) (CreateParams, error) {
+	p := CreateParams{
+		IP:        clashString(fields, "server"),
+		Username:  clashString(fields, "username"),
+		Password:  clashString(fields, "password"),
+		ProxyType: strings.ToLower(clashString(fields, "type")),
+	}
+
+	extra := make(map[string]interface{})
+	for k, v := range fields {
+		if !clashStandardKeys[k] {
+			extra[k] = v
+		}
+	}
+
+	switch p.ProxyType {
+	case models.ProxyTypeSOCKS5, models.ProxyTypeHTTP, models.ProxyTypeTrojan, models.ProxyTypeSS:
+	case models.ProxyTypeVMess:
+		p.Password = clashString(fields, "uuid")
+		delete(extra, "uuid")
+	default:
+		return p, fmt.Errorf("%w %s", errUnsupportedType, p.ProxyType)
+	}
+
+	if err := setPort(&p, clashString(fields, "port")); err != nil {
+		return p, err
+	}
+	if len(extra) > 0 {
+		b, err := json.Marshal(extra)
+		if err != nil {
+			return p, fmt.Errorf("协议参数无法序列化: %w", err)
+		}
+		p.Extra = string(b)
+	}
+	return p, nil
+}
+
+// clashRoot 解析 YAML 并返回顶层映射
+func clashRoot(content []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(content)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("YAML 解析失败: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("YAML 顶层应为映射")
+	}
+	return doc.Content[0], nil
+}
+
+// clashSection 返回顶层映射中的指定段，不存在时返回 nil
+func clashSection(root *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == key {
+			return root.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func clashString(fields map[string]interface{}, key string) string {
+	v, ok := fields[key]
+	if !ok || v == nil {
+		return ""
+	}
+	return strings.TrimSpace(fmt.Sprint(v))
+}
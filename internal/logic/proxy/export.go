@@ -0,0 +1,66 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/maxliu9403/ProxyHub/internal/common"
+	"github.com/maxliu9403/ProxyHub/internal/pkg/export"
+	"github.com/maxliu9403/ProxyHub/models"
+	"github.com/maxliu9403/common/logger"
+)
+
+// redacted 脱敏后的密码
+const redacted = "******"
+
+type ExportParams struct {
+	models.GetListParams        // 与 /api/proxy/search 相同的过滤条件，Limit 为 0 时导出全部
+	Format               string `json:"Format,omitempty" binding:"omitempty,oneof=csv json ndjson"` // 导出格式，默认 csv
+	WithPassword         bool   `json:"WithPassword,omitempty"`                                     // 导出明文密码，默认脱敏
+}
+
+// Export 按查询条件逐行读取代理并写出，不把整张表加载到内存
+func (s *Svc) Export(params ExportParams, w io.Writer) error {
+	enc, err := export.NewEncoder(params.Format, w, export.Columns(models.Proxy{}))
+	if err != nil {
+		return common.NewErrorCode(common.ErrInvalidParams, err)
+	}
+
+	err = s.getRepo().Iterate(params.GetListParams, func(p *models.Proxy) error {
+		if !params.WithPassword {
+			redactProxy(p)
+		}
+		return enc.Encode(p)
+	})
+	if err == nil {
+		err = enc.Close()
+	}
+	if err != nil {
+		logger.ErrorfWithTrace(s.Ctx, "export proxies failed: %s", err.Error())
+		return common.NewErrorCode(common.ErrExport, err)
+	}
+	return nil
+}
+
+// redactProxy 脱敏密码（vmess 为 uuid），以及协议参数中名称含 password 的配置项，如 obfs-password
+func redactProxy(p *models.Proxy) {
+	if p.Password != "" {
+		p.Password = redacted
+	}
+	if p.Extra == "" {
+		return
+	}
+	extra := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(p.Extra), &extra); err != nil {
+		p.Extra = redacted
+		return
+	}
+	for k := range extra {
+		if strings.Contains(strings.ToLower(k), "password") {
+			extra[k] = redacted
+		}
+	}
+	b, _ := json.Marshal(extra)
+	p.Extra = string(b)
+}
@@ -0,0 +1,24 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+func TestRedactProxy(t *testing.T) {
+	p := &models.Proxy{Password: "secret", Extra: `{"cipher":"aes-256-gcm","obfs-password":"x"}`}
+	redactProxy(p)
+	if p.Password != redacted {
+		t.Errorf("password = %q", p.Password)
+	}
+	if p.Extra != `{"cipher":"aes-256-gcm","obfs-password":"******"}` {
+		t.Errorf("extra = %s", p.Extra)
+	}
+
+	p = &models.Proxy{Extra: "not json"}
+	redactProxy(p)
+	if p.Password != "" || p.Extra != redacted {
+		t.Errorf("got %+v", p)
+	}
+}
@@ -0,0 +1,164 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/maxliu9403/ProxyHub/internal/common"
+	"github.com/maxliu9403/ProxyHub/models"
+	"github.com/maxliu9403/common/logger"
+)
+
+// 导入结果状态
+const (
+	ImportAccepted    = "accepted"    // 已接受（DryRun 时表示可导入）
+	ImportDuplicate   = "duplicate"   // IP 与本次导入的其他行或已有代理重复
+	ImportMalformed   = "malformed"   // 格式错误或校验未通过
+	ImportUnsupported = "unsupported" // 代理类型不受支持
+)
+
+type ImportParams struct {
+	GroupID  int64             `json:"GroupID" binding:"required,gt=0"`                 // 所属分组ID
+	Source   string            `json:"Source" binding:"required"`                       // 来源类型，如 ipfoxy
+	Format   string            `json:"Format" binding:"omitempty,oneof=text csv clash"` // 导入格式，默认 text
+	Content  string            `json:"Content" binding:"required"`                      // 导入内容；Clash 格式下 file 类型的 proxy-provider 仅命令行导入支持
+	Mapping  map[string]string `json:"Mapping,omitempty"`                               // CSV 列映射，字段名(IP/Port/Username/Password/ProxyType/Priority/Weight) -> 表头名
+	Priority int               `json:"Priority,omitempty" binding:"omitempty,gte=0"`    // 未单独指定时使用的优先级
+	Weight   int               `json:"Weight,omitempty" binding:"omitempty,gt=0"`       // 未单独指定时使用的权重
+	DryRun   bool              `json:"DryRun,omitempty"`                                // 只校验并返回报告，不写入
+}
+
+// ImportLine 单行导入结果
+type ImportLine struct {
+	Line     int    `json:"Line"`               // 行号，从 1 开始；来自 proxy-provider 时为其内容中的行号
+	Raw      string `json:"Raw"`                // 原始内容（Clash 格式为代理名称）
+	Provider string `json:"Provider,omitempty"` // 所属 proxy-provider，仅 Clash 格式
+	Status   string `json:"Status"`             // accepted | duplicate | malformed | unsupported
+	Message  string `json:"Message"`            // 重复或错误原因
+	IP       string `json:"IP"`                 // 规范化后的 IP 或域名
+	Port     int64  `json:"Port"`               // 端口
+}
+
+type ImportResp struct {
+	Accepted     int          `json:"Accepted"`     // 接受行数
+	Duplicate    int          `json:"Duplicate"`    // 重复行数
+	Malformed    int          `json:"Malformed"`    // 错误行数
+	Unsupported  int          `json:"Unsupported"`  // 类型不受支持的行数
+	CreatedCount int          `json:"CreatedCount"` // 实际创建数，DryRun 时为 0
+	Lines        []ImportLine `json:"Lines"`        // 逐行报告
+}
+
+// Import 解析文本/CSV/Clash 内容，规范化、去重后批量创建代理，并返回逐行报告。
+// 接口导入不读取 http/file 类型的 proxy-provider，避免服务端代为请求任意地址，需通过命令行导入
+func (s *Svc) Import(params ImportParams) (*ImportResp, error) {
+	return s.ImportWithLoader(params, nil)
+}
+
+// ImportWithLoader 同 Import，由调用方指定 proxy-provider 的读取方式
+func (s *Svc) ImportWithLoader(params ImportParams, load ProviderLoader) (*ImportResp, error) {
+	entries, err := parseImport(params.Format, params.Content, params.Mapping, load)
+	if err != nil {
+		return nil, common.NewErrorCode(common.ErrInvalidParams, err)
+	}
+
+	existing, err := s.existingIPs(entries)
+	if err != nil {
+		logger.ErrorfWithTrace(s.Ctx, "query existing proxies failed: %s", err.Error())
+		return nil, common.NewErrorCode(common.ErrCreateProxy, err)
+	}
+
+	resp, accepted := buildImportReport(entries, existing)
+	if params.DryRun || len(accepted) == 0 {
+		return resp, nil
+	}
+
+	for i := range accepted {
+		accepted[i].Source = params.Source
+		if accepted[i].Priority == 0 {
+			accepted[i].Priority = params.Priority
+		}
+		if accepted[i].Weight == 0 {
+			accepted[i].Weight = params.Weight
+		}
+	}
+	result, err := s.CreateBatch(CreateBatchParams{GroupID: params.GroupID, Proxies: accepted})
+	if err != nil {
+		return nil, err
+	}
+	resp.CreatedCount = result.CreatedCount
+	return resp, nil
+}
+
+// existingIPs 查询解析结果中已存在于库中的 IP
+func (s *Svc) existingIPs(entries []importEntry) (map[string]bool, error) {
+	ips := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.err == nil {
+			ips = append(ips, e.params.IP)
+		}
+	}
+	existing := make(map[string]bool)
+	if len(ips) == 0 {
+		return existing, nil
+	}
+
+	proxies := make([]*models.Proxy, 0)
+	if _, err := s.getRepo().GetList(models.GetListParams{IPs: ips}, &models.Proxy{}, &proxies); err != nil {
+		return nil, err
+	}
+	for _, p := range proxies {
+		existing[p.IP] = true
+	}
+	return existing, nil
+}
+
+// buildImportReport 生成逐行报告并返回可创建的代理。绑定、使用数和冷却都按 IP 定位代理，
+// 因此同一 IP（或域名）只接受第一次出现，不同端口也视为重复。订阅暂不能渲染的类型
+// （ss/trojan/vmess）报告为不支持，避免入库后计入库存却永远不会被分配
+func buildImportReport(entries []importEntry, existing map[string]bool) (*ImportResp, []CreateParams) {
+	resp := &ImportResp{Lines: make([]ImportLine, 0, len(entries))}
+	accepted := make([]CreateParams, 0, len(entries))
+	firstLine := make(map[string]int)
+
+	for _, e := range entries {
+		line := ImportLine{Line: e.line, Raw: e.raw, Provider: e.provider}
+		if errors.Is(e.err, errUnsupportedType) {
+			line.Status = ImportUnsupported
+			line.Message = e.err.Error()
+			resp.Unsupported++
+			resp.Lines = append(resp.Lines, line)
+			continue
+		}
+		if e.err != nil {
+			line.Status = ImportMalformed
+			line.Message = e.err.Error()
+			resp.Malformed++
+			resp.Lines = append(resp.Lines, line)
+			continue
+		}
+
+		line.IP, line.Port = e.params.IP, e.params.Port
+		ip := e.params.IP
+		switch {
+		case !(&models.Proxy{ProxyType: e.params.ProxyType}).Servable():
+			line.Status = ImportUnsupported
+			line.Message = fmt.Sprintf("订阅渲染暂不支持 %s 类型，不予导入", e.params.ProxyType)
+			resp.Unsupported++
+		case firstLine[ip] > 0:
+			line.Status = ImportDuplicate
+			line.Message = fmt.Sprintf("与第 %d 行 IP 重复", firstLine[ip])
+			resp.Duplicate++
+		case existing[ip]:
+			line.Status = ImportDuplicate
+			line.Message = "代理IP已存在"
+			resp.Duplicate++
+		default:
+			line.Status = ImportAccepted
+			resp.Accepted++
+			firstLine[ip] = e.line
+			accepted = append(accepted, e.params)
+		}
+		resp.Lines = append(resp.Lines, line)
+	}
+	return resp, accepted
+}
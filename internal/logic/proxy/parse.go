@@ -0,0 +1,289 @@
+package proxy
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+// 导入格式
+const (
+	ImportFormatText  = "text"  // 每行一个代理：ip:port:user:pass、user:pass@ip:port 等
+	ImportFormatCSV   = "csv"   // 带表头的 CSV，按列映射取值
+	ImportFormatClash = "clash" // Clash/mihomo YAML 的 proxies 与 proxy-providers 段
+)
+
+// errUnsupportedType 代理类型不受支持，报告中单独计为 unsupported
+var errUnsupportedType = errors.New("不支持的代理类型")
+
+// importEntry 解析出的一条待导入记录
+type importEntry struct {
+	line     int          // 在原始内容中的行号，从 1 开始；来自 proxy-provider 时为其内容中的行号
+	raw      string       // 原始内容
+	provider string       // 所属 proxy-provider 名称，直接写在 proxies 段时为空
+	params   CreateParams // 解析结果，err 不为空时无意义
+	err      error        // 解析或校验失败的原因
+}
+
+// parseImport 按格式解析导入内容，整体格式错误（如 CSV 缺少必要列、YAML 不合法）时返回 error
+func parseImport(format, content string, mapping map[string]string, load ProviderLoader) ([]importEntry, error) {
+	switch format {
+	case "", ImportFormatText:
+		return parseText(content), nil
+	case ImportFormatCSV:
+		return parseCSV(content, mapping)
+	case ImportFormatClash:
+		return parseClash(content, load)
+	default:
+		return nil, fmt.Errorf("不支持的导入格式 %s", format)
+	}
+}
+
+// parseText 解析纯文本，空行和 # 开头的注释行跳过
+func parseText(content string) []importEntry {
+	entries := make([]importEntry, 0)
+	for i, raw := range strings.Split(content, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p, err := parseProxyLine(line)
+		if err == nil {
+			err = normalizeParams(&p)
+		}
+		entries = append(entries, importEntry{line: i + 1, raw: line, params: p, err: err})
+	}
+	return entries
+}
+
+// parseProxyLine 解析单行代理，支持以下写法（可带 socks5:// 或 http:// 前缀）：
+//
+//	ip:port:user:pass
+//	user:pass:ip:port
+//	user:pass@ip:port
+//	ip:port@user:pass
+func parseProxyLine(line string) (CreateParams, error) {
+	var p CreateParams
+	if i := strings.Index(line, "://"); i >= 0 {
+		switch scheme := strings.ToLower(line[:i]); scheme {
+		case "socks5", "socks5h":
+			p.ProxyType = models.ProxyTypeSOCKS5
+		case "http":
+			p.ProxyType = models.ProxyTypeHTTP
+		default:
+			return p, fmt.Errorf("%w %s", errUnsupportedType, scheme)
+		}
+		line = line[i+3:]
+	}
+
+	if at := strings.LastIndex(line, "@"); at >= 0 {
+		left, right := line[:at], line[at+1:]
+		// ip:port@user:pass 写法
+		if host, _, err := net.SplitHostPort(left); err == nil && net.ParseIP(host) != nil {
+			left, right = right, left
+		}
+		user, pass, ok := strings.Cut(left, ":")
+		if !ok {
+			return p, errors.New("认证信息应为 user:pass")
+		}
+		host, port, err := net.SplitHostPort(right)
+		if err != nil {
+			return p, fmt.Errorf("地址应为 ip:port: %w", err)
+		}
+		p.IP, p.Username, p.Password = host, user, pass
+		return p, setPort(&p, port)
+	}
+
+	parts := strings.Split(line, ":")
+	switch {
+	case len(parts) < 4:
+		return p, errors.New("缺少用户名或密码，应为 ip:port:user:pass 或 user:pass@ip:port")
+	case net.ParseIP(parts[0]) != nil:
+		// 密码中允许出现冒号
+		p.IP, p.Username, p.Password = parts[0], parts[2], strings.Join(parts[3:], ":")
+		return p, setPort(&p, parts[1])
+	case len(parts) == 4 && net.ParseIP(parts[2]) != nil:
+		p.Username, p.Password, p.IP = parts[0], parts[1], parts[2]
+		return p, setPort(&p, parts[3])
+	default:
+		return p, errors.New("无法识别的格式")
+	}
+}
+
+// csvFields CSV 可映射的字段
+var csvFields = []string{"IP", "Port", "Username", "Password", "ProxyType", "Priority", "Weight"}
+
+// parseCSV 解析带表头的 CSV，mapping 为 字段名 -> 表头名，未指定的字段按同名表头（不区分大小写）匹配
+func parseCSV(content string, mapping map[string]string) ([]importEntry, error) {
+	r := csv.NewReader(strings.NewReader(content))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("读取 CSV 表头失败: %w", err)
+	}
+	headerIndex := make(map[string]int, len(header))
+	for i, h := range header {
+		headerIndex[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+
+	columns := make(map[string]int)
+	for _, field := range csvFields {
+		name := field
+		if m, ok := mapping[field]; ok {
+			name = m
+		}
+		if i, ok := headerIndex[strings.ToLower(name)]; ok {
+			columns[field] = i
+		} else if _, ok := mapping[field]; ok {
+			return nil, fmt.Errorf("CSV 中没有列 %s", name)
+		}
+	}
+	for _, required := range []string{"IP", "Port", "Username", "Password"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("CSV 缺少 %s 列，请通过列映射指定", required)
+		}
+	}
+
+	entries := make([]importEntry, 0)
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var perr *csv.ParseError
+			if errors.As(err, &perr) {
+				entries = append(entries, importEntry{line: perr.StartLine, err: err})
+				continue
+			}
+			return nil, err
+		}
+		line, _ := r.FieldPos(0)
+
+		get := func(field string) string {
+			i, ok := columns[field]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		entry := importEntry{line: line, raw: strings.Join(record, ",")}
+		entry.params = CreateParams{
+			IP:        get("IP"),
+			Username:  get("Username"),
+			Password:  get("Password"),
+			ProxyType: get("ProxyType"),
+		}
+		entry.err = setPort(&entry.params, get("Port"))
+		if entry.err == nil {
+			entry.err = setInt(&entry.params.Priority, "Priority", get("Priority"))
+		}
+		if entry.err == nil {
+			entry.err = setInt(&entry.params.Weight, "Weight", get("Weight"))
+		}
+		if entry.err == nil {
+			entry.err = normalizeParams(&entry.params)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// normalizeParams 规范化并校验解析结果：IP 统一为标准写法，域名转小写，代理类型默认 socks5。
+// 认证信息按代理类型由 checkCredentials 校验
+func normalizeParams(p *CreateParams) error {
+	host := strings.Trim(strings.TrimSpace(p.IP), "[]")
+	if ip := net.ParseIP(host); ip != nil {
+		p.IP = ip.String()
+	} else if isHostname(host) {
+		p.IP = strings.ToLower(host)
+	} else {
+		return fmt.Errorf("无效的地址 %q", p.IP)
+	}
+	if p.Port <= 0 || p.Port > 65535 {
+		return fmt.Errorf("无效的端口 %d", p.Port)
+	}
+	p.Username = strings.TrimSpace(p.Username)
+	p.Password = strings.TrimSpace(p.Password)
+	p.ProxyType = strings.ToLower(strings.TrimSpace(p.ProxyType))
+	if p.ProxyType == "" {
+		p.ProxyType = models.ProxyTypeSOCKS5
+	}
+	return checkCredentials(p)
+}
+
+// checkCredentials 按代理类型校验必填的认证信息：socks5/http 需要用户名和密码，
+// ss 需要密码和 Extra 中的 cipher，trojan 需要密码，vmess 需要 uuid（存放在 Password）
+func checkCredentials(p *CreateParams) error {
+	switch p.ProxyType {
+	case "", models.ProxyTypeSOCKS5, models.ProxyTypeHTTP:
+		if p.Username == "" || p.Password == "" {
+			return errors.New("缺少用户名或密码")
+		}
+	case models.ProxyTypeSS:
+		if p.Password == "" {
+			return errors.New("缺少密码")
+		}
+		var extra struct {
+			Cipher string `json:"cipher"`
+		}
+		if p.Extra == "" || json.Unmarshal([]byte(p.Extra), &extra) != nil || extra.Cipher == "" {
+			return errors.New("ss 代理缺少 cipher")
+		}
+	case models.ProxyTypeTrojan:
+		if p.Password == "" {
+			return errors.New("缺少密码")
+		}
+	case models.ProxyTypeVMess:
+		if p.Password == "" {
+			return errors.New("vmess 代理缺少 uuid")
+		}
+	default:
+		return fmt.Errorf("%w %s", errUnsupportedType, p.ProxyType)
+	}
+	return nil
+}
+
+// isHostname 粗略校验域名：只允许字母、数字、'-' 和 '.'
+func isHostname(s string) bool {
+	if s == "" || len(s) > 253 || strings.HasPrefix(s, ".") || strings.HasPrefix(s, "-") {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func setPort(p *CreateParams, s string) error {
+	port, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return fmt.Errorf("无效的端口 %q", s)
+	}
+	p.Port = port
+	return nil
+}
+
+func setInt(dst *int, field, s string) error {
+	if s == "" {
+		return nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("无效的 %s %q", field, s)
+	}
+	*dst = v
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/maxliu9403/ProxyHub/internal/common"
 	"github.com/maxliu9403/ProxyHub/internal/logic/group"
+	"github.com/maxliu9403/ProxyHub/internal/pkg/geoip"
 	"github.com/maxliu9403/ProxyHub/models"
 	"github.com/maxliu9403/ProxyHub/models/factory"
 	"github.com/maxliu9403/ProxyHub/models/repo"
@@ -26,6 +27,11 @@ func (s *Svc) getRepo() repo.ProxyRepo {
 	return factory.ProxyRepo(s.DB)
 }
 
+func (s *Svc) getLatencyRepo() repo.ProxyLatencyRepo {
+	s.DB = gormdb.Cli(s.Ctx)
+	return factory.ProxyLatencyRepo(s.DB)
+}
+
 func (s *Svc) getGroupRepo() repo.GroupsRepo {
 	s.DB = gormdb.Cli(s.Ctx)
 	return factory.GroupsRepo(s.DB)
@@ -34,10 +40,13 @@ func (s *Svc) getGroupRepo() repo.GroupsRepo {
 type CreateParams struct {
 	IP        string `json:"IP" binding:"required"`
 	Port      int64  `json:"Port,omitempty" binding:"omitempty,gt=0,lte=65535"`
-	Username  string `json:"Username" binding:"required"`
-	Password  string `json:"Password" binding:"required"`
+	Username  string `json:"Username"`                    // socks5/http 必填，按类型的校验见 checkCredentials
+	Password  string `json:"Password" binding:"required"` // ss/trojan 为密码，vmess 为 uuid
 	Source    string `json:"Source" binding:"required"`
-	ProxyType string `json:"ProxyType,omitempty" binding:"omitempty,oneof=socks5"`
+	ProxyType string `json:"ProxyType,omitempty" binding:"omitempty,oneof=socks5 http ss trojan vmess"`
+	Extra     string `json:"Extra,omitempty" binding:"omitempty,json"`     // 协议相关参数JSON，如 ss 的 cipher
+	Priority  int    `json:"Priority,omitempty" binding:"omitempty,gte=0"` // 优先级，数值越大越优先，默认 0
+	Weight    int    `json:"Weight,omitempty" binding:"omitempty,gt=0"`    // 同优先级内的选择权重，默认 1
 }
 
 type CreateBatchParams struct {
@@ -46,19 +55,56 @@ type CreateBatchParams struct {
 }
 
 func (p CreateParams) ToModel(groupID int64) *models.Proxy {
+	weight := p.Weight
+	if weight == 0 {
+		weight = 1
+	}
 	return &models.Proxy{
 		IP:        p.IP,
 		Port:      p.Port,
 		Username:  p.Username,
 		ProxyType: p.ProxyType,
+		Extra:     p.Extra,
 		Password:  p.Password,
 		Source:    p.Source,
 		GroupID:   groupID,
+		Priority:  p.Priority,
+		Weight:    weight,
+	}
+}
+
+// applyGeo 按入口 IP 补全地理位置及 ASN 信息，GeoIP 未启用或未收录时跳过
+func applyGeo(p *models.Proxy) {
+	info, found := geoip.Lookup(p.IP)
+	if !found {
+		return
+	}
+	p.Country = info.Country
+	p.Region = info.Region
+	p.City = info.City
+	p.Timezone = info.Timezone
+	p.ASN = info.ASN
+	p.ASOrg = info.ASOrg
+}
+
+// movedFields 入口 IP 变更时按新 IP 重新补全地理位置，并清空旧 IP 的出口检测结果，由下次检测重新判定
+func movedFields(ip string) map[string]interface{} {
+	p := &models.Proxy{IP: ip}
+	applyGeo(p)
+	return map[string]interface{}{
+		"country":         p.Country,
+		"region":          p.Region,
+		"city":            p.City,
+		"timezone":        p.Timezone,
+		"asn":             p.ASN,
+		"as_org":          p.ASOrg,
+		"exit_ip":         "",
+		"exit_ip_changed": false,
 	}
 }
 
 type Invalid struct {
-	IP      int64  `json:"IP"`
+	IP      string `json:"IP"`
 	Message string `json:"Message"` // 错误信息
 }
 
@@ -82,7 +128,12 @@ func (s *Svc) CreateBatch(params CreateBatchParams) (resp *CreateBatchResult, er
 	validProxies := make([]*models.Proxy, 0)
 	// 用于存储转换后的模型
 	for _, p := range params.Proxies {
+		if err := checkCredentials(&p); err != nil {
+			invalidProxies = append(invalidProxies, Invalid{IP: p.IP, Message: err.Error()})
+			continue
+		}
 		model := p.ToModel(params.GroupID)
+		applyGeo(model)
 		// TODO 实现并发校验ip的有效性
 		validProxies = append(validProxies, model)
 	}
@@ -106,22 +157,28 @@ func (s *Svc) CreateBatch(params CreateBatchParams) (resp *CreateBatchResult, er
 }
 
 type UpdateParams struct {
-	ID        int64   `json:"ID" binding:"required"`                             // ID 必填
-	IP        *string `json:"IP" binding:"omitempty"`                            // IP
-	Port      *int    `json:"Port,omitempty" binding:"omitempty,gt=0,lte=65535"` // 端口
-	Username  *string `json:"Username,omitempty"`                                // 用户名
-	Password  *string `json:"Password,omitempty"`                                // 密码
-	GroupID   *int64  `json:"GroupID,omitempty"  binding:"omitempty,gt=0"`       // 组ID
-	ProxyType *string `json:"ProxyType,omitempty"`                               // 代理类型，socks5
+	ID        int64   `json:"ID" binding:"required"`                                                     // ID 必填
+	IP        *string `json:"IP" binding:"omitempty"`                                                    // IP
+	Port      *int    `json:"Port,omitempty" binding:"omitempty,gt=0,lte=65535"`                         // 端口
+	Username  *string `json:"Username,omitempty"`                                                        // 用户名
+	Password  *string `json:"Password,omitempty"`                                                        // 密码
+	GroupID   *int64  `json:"GroupID,omitempty"  binding:"omitempty,gt=0"`                               // 组ID
+	ProxyType *string `json:"ProxyType,omitempty" binding:"omitempty,oneof=socks5 http ss trojan vmess"` // 代理类型
+	Extra     *string `json:"Extra,omitempty" binding:"omitempty,json"`                                  // 协议相关参数JSON
+	Priority  *int    `json:"Priority,omitempty" binding:"omitempty,gte=0"`                              // 优先级，数值越大越优先
+	Weight    *int    `json:"Weight,omitempty" binding:"omitempty,gt=0"`                                 // 同优先级内的选择权重
+	AckExitIP bool    `json:"AckExitIP,omitempty"`                                                       // 确认出口IP变更，清除 ExitIPChanged 标记
 }
 
 func (s *Svc) Update(params UpdateParams) error {
 	// 先校验代理是否存在
-	err := s.getRepo().GetByID(&models.Proxy{}, params.ID)
+	current := &models.Proxy{}
+	err := s.getRepo().GetByID(current, params.ID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return common.NewErrorCode(common.ErrUpdateProxy, errors.New("代理不存在"))
 		}
+		return common.NewErrorCode(common.ErrUpdateProxy, err)
 	}
 
 	updateFields := map[string]interface{}{}
@@ -130,6 +187,11 @@ func (s *Svc) Update(params UpdateParams) error {
 	}
 	if params.IP != nil {
 		updateFields["ip"] = *params.IP
+		if *params.IP != current.IP {
+			for k, v := range movedFields(*params.IP) {
+				updateFields[k] = v
+			}
+		}
 	}
 	if params.Username != nil {
 		updateFields["username"] = *params.Username
@@ -151,6 +213,18 @@ func (s *Svc) Update(params UpdateParams) error {
 	if params.ProxyType != nil {
 		updateFields["proxy_type"] = *params.ProxyType
 	}
+	if params.Extra != nil {
+		updateFields["extra"] = *params.Extra
+	}
+	if params.Priority != nil {
+		updateFields["priority"] = *params.Priority
+	}
+	if params.Weight != nil {
+		updateFields["weight"] = *params.Weight
+	}
+	if params.AckExitIP {
+		updateFields["exit_ip_changed"] = false
+	}
 
 	err = s.getRepo().Update(params.ID, updateFields)
 	if err != nil {
@@ -226,3 +300,25 @@ func (s *Svc) GetByIP(ip string) (*models.Proxy, error) {
 	}
 	return proxy, nil
 }
+
+// latencyHistoryLimit 详情中返回的最近延迟记录数
+const latencyHistoryLimit = 20
+
+type DetailResp struct {
+	models.Proxy
+	LatencyHistory []*models.ProxyLatency `json:"LatencyHistory"` // 最近的健康检测延迟记录，按时间倒序
+}
+
+func (s *Svc) DetailByIP(ip string) (*DetailResp, error) {
+	proxy, err := s.GetByIP(ip)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.getLatencyRepo().ListRecent(proxy.ID, latencyHistoryLimit)
+	if err != nil {
+		logger.ErrorfWithTrace(s.Ctx, "list latency history of %s failed: %s", ip, err.Error())
+		return nil, err
+	}
+	return &DetailResp{Proxy: *proxy, LatencyHistory: history}, nil
+}
@@ -0,0 +1,72 @@
+package subscribe
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/maxliu9403/ProxyHub/models"
+	"github.com/maxliu9403/common/logger"
+)
+
+// selectBackupProxies 按负载从低到高选出 n 个备用代理，排除当前绑定的 IP；同负载按 IP 排序保证结果稳定
+func selectBackupProxies(proxies []models.Proxy, maxOnline int64, selectedIP string, n int) []models.Proxy {
+	remaining := make([]models.Proxy, 0, len(proxies))
+	for _, p := range proxies {
+		if p.IP != selectedIP {
+			remaining = append(remaining, p)
+		}
+	}
+
+	backups := make([]models.Proxy, 0, n)
+	for len(backups) < n {
+		candidates := selectLeastUsedProxies(remaining, maxOnline)
+		if len(candidates) == 0 {
+			break
+		}
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].IP < candidates[j].IP })
+
+		picked := make(map[string]bool, len(candidates))
+		for _, c := range candidates {
+			if len(backups) >= n {
+				break
+			}
+			backups = append(backups, c)
+			picked[c.IP] = true
+		}
+		remaining = filterUntriedProxies(remaining, picked)
+	}
+	return backups
+}
+
+// attachBackups 为模板数据附加备用代理，备用代理不计入 InUseCount，仅记录到模拟器的 BackupIPs 便于查看；
+// 备用代理未变化时不写库，分组关闭备用代理后清空旧记录；record 为 false 时只读，不写库
+func (s *Svc) attachBackups(data *ClashTemplateData, emulator *models.Emulator, group *models.Groups, record bool) error {
+	var backups []models.Proxy
+	if group.BackupCount > 0 {
+		err, proxies := s.getProxies(group.ID)
+		if err != nil {
+			return err
+		}
+		pool := filterAssignable(filterGeo(proxies, emulator), emulator, time.Now().Unix())
+		backups = selectBackupProxies(pool, int64(group.MaxOnline), data.Proxy.Server, group.BackupCount)
+	}
+
+	ips := make([]string, 0, len(backups))
+	for i := range backups {
+		data.Backups = append(data.Backups, newProxyData(fmt.Sprintf("backup-%d", i+1), &backups[i]))
+		ips = append(ips, backups[i].IP)
+	}
+
+	backupIPs := strings.Join(ips, ",")
+	if !record || backupIPs == emulator.BackupIPs {
+		return nil
+	}
+	if err := s.getEmulatorRepo().Update(emulator.UUID, map[string]interface{}{"backup_ips": backupIPs}); err != nil {
+		return fmt.Errorf("更新模拟器备用IP失败: %w", err)
+	}
+	emulator.BackupIPs = backupIPs
+	logger.InfofWithTrace(s.Ctx, "模拟器 %s 备用IP: [%s]", emulator.UUID, backupIPs)
+	return nil
+}
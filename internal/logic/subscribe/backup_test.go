@@ -0,0 +1,28 @@
+package subscribe
+
+import (
+	"testing"
+
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+func TestSelectBackupProxies(t *testing.T) {
+	proxies := []models.Proxy{
+		{IP: "10.0.0.1", InUseCount: 0},
+		{IP: "10.0.0.2", InUseCount: 2},
+		{IP: "10.0.0.3", InUseCount: 1},
+		{IP: "10.0.0.4", InUseCount: 3}, // 已满
+		{IP: "10.0.0.5", InUseCount: 0},
+	}
+
+	backups := selectBackupProxies(proxies, 3, "10.0.0.1", 3)
+	want := []string{"10.0.0.5", "10.0.0.3", "10.0.0.2"}
+	if len(backups) != len(want) {
+		t.Fatalf("expect %d backups, got %d", len(want), len(backups))
+	}
+	for i, ip := range want {
+		if backups[i].IP != ip {
+			t.Fatalf("backup[%d] expect %s, got %s", i, ip, backups[i].IP)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package subscribe
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Format 订阅输出格式
+type Format string
+
+const (
+	FormatClash        Format = "clash"
+	FormatSingBox      Format = "singbox"
+	FormatSurge        Format = "surge"
+	FormatShadowrocket Format = "shadowrocket"
+	FormatQuantumultX  Format = "quanx"
+	FormatLoon         Format = "loon"
+	FormatXray         Format = "xray"
+	FormatURIList      Format = "base64"
+)
+
+// formatAlias 兼容 format 参数的常见写法
+var formatAlias = map[string]Format{
+	"clash":        FormatClash,
+	"mihomo":       FormatClash,
+	"yaml":         FormatClash,
+	"singbox":      FormatSingBox,
+	"sing-box":     FormatSingBox,
+	"surge":        FormatSurge,
+	"shadowrocket": FormatShadowrocket,
+	"quanx":        FormatQuantumultX,
+	"quantumultx":  FormatQuantumultX,
+	"loon":         FormatLoon,
+	"xray":         FormatXray,
+	"v2ray":        FormatXray,
+	"base64":       FormatURIList,
+	"uri":          FormatURIList,
+}
+
+// uaKeywords 按顺序匹配 User-Agent 关键字，先匹配先生效
+var uaKeywords = []struct {
+	keyword string
+	format  Format
+}{
+	{"v2rayn", FormatURIList},
+	{"shadowrocket", FormatShadowrocket},
+	{"quantumult", FormatQuantumultX},
+	{"surge", FormatSurge},
+	{"loon", FormatLoon},
+	{"sing-box", FormatSingBox},
+	{"singbox", FormatSingBox},
+	{"clash", FormatClash},
+	{"mihomo", FormatClash},
+	{"stash", FormatClash},
+}
+
+// ParseFormat 解析 format 参数，空值返回 Clash
+func ParseFormat(s string) (Format, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return FormatClash, nil
+	}
+	if f, ok := formatAlias[s]; ok {
+		return f, nil
+	}
+	return "", fmt.Errorf("不支持的订阅格式: %s", s)
+}
+
+// DetectFormat 优先使用 format 参数，其次根据 User-Agent 判断，都没有则默认 Clash
+func DetectFormat(format, userAgent string) (Format, error) {
+	if strings.TrimSpace(format) != "" {
+		return ParseFormat(format)
+	}
+
+	ua := strings.ToLower(userAgent)
+	for _, k := range uaKeywords {
+		if strings.Contains(ua, k.keyword) {
+			return k.format, nil
+		}
+	}
+	return FormatClash, nil
+}
+
+// ContentType 返回格式对应的响应类型
+func (f Format) ContentType() string {
+	if r, ok := renderers[f]; ok {
+		return r.ContentType()
+	}
+	return contentTypePlain
+}
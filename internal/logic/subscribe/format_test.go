@@ -0,0 +1,46 @@
+package subscribe
+
+import "testing"
+
+func TestDetectFormat(t *testing.T) {
+	cases := []struct {
+		format    string
+		userAgent string
+		want      Format
+		wantErr   bool
+	}{
+		{"", "", FormatClash, false},
+		{"", "ClashMetaForAndroid/2.10.1", FormatClash, false},
+		{"", "mihomo/1.18.3", FormatClash, false},
+		{"", "Stash/2.4.6", FormatClash, false},
+		{"", "sing-box 1.8.0", FormatSingBox, false},
+		{"", "SFI/1.8.0 (singbox)", FormatSingBox, false},
+		{"", "Surge iOS/2920", FormatSurge, false},
+		{"", "Shadowrocket/2070 CFNetwork/1410.0.3", FormatShadowrocket, false},
+		{"", "Quantumult%20X/1.4.1", FormatQuantumultX, false},
+		{"", "Loon/3.1.3", FormatLoon, false},
+		{"", "v2rayN/6.23", FormatURIList, false},
+		{"", "curl/8.4.0", FormatClash, false},
+		// 同时包含多个关键字时按 uaKeywords 顺序匹配
+		{"", "Shadowrocket (clash compatible)", FormatShadowrocket, false},
+		// format 参数优先于 User-Agent
+		{"singbox", "ClashMetaForAndroid/2.10.1", FormatSingBox, false},
+		{" Sing-Box ", "Surge iOS/2920", FormatSingBox, false},
+		{"v2ray", "Loon/3.1.3", FormatXray, false},
+		{"uri", "", FormatURIList, false},
+		{"yaml", "Shadowrocket/2070", FormatClash, false},
+		// 非法的 format 参数直接报错，不回退到 User-Agent
+		{"unknown", "ClashMetaForAndroid/2.10.1", "", true},
+		{"   ", "Loon/3.1.3", FormatLoon, false},
+	}
+	for _, c := range cases {
+		got, err := DetectFormat(c.format, c.userAgent)
+		if (err != nil) != c.wantErr {
+			t.Errorf("DetectFormat(%q, %q) err = %v, wantErr %v", c.format, c.userAgent, err, c.wantErr)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("DetectFormat(%q, %q) = %s, want %s", c.format, c.userAgent, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package subscribe
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// jsonString 输出 JSON 编码后的值
+func jsonString(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// quote 输出双引号字符串，转义规则同时兼容 JSON 与 YAML
+func quote(v interface{}) (string, error) {
+	return jsonString(fmt.Sprint(v))
+}
+
+// squote 输出 YAML 单引号字符串，内部单引号转义为两个单引号
+func squote(v interface{}) string {
+	return "'" + strings.ReplaceAll(fmt.Sprint(v), "'", "''") + "'"
+}
+
+// dquote 输出 Surge/Shadowrocket/Loon/Quantumult X 配置中的双引号字段，内部的 \ 和 " 用反斜杠转义，
+// 使逗号、等号等分隔符不会截断字段；这些格式按行解析，值中含换行时报错
+func dquote(v interface{}) (string, error) {
+	s := fmt.Sprint(v)
+	if strings.ContainsAny(s, "\r\n") {
+		return "", fmt.Errorf("dquote 的值不能包含换行: %q", s)
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`, nil
+}
+
+// defaultValue 值为零值（nil、空字符串、数值 0 等）时返回默认值，字符串 "0" 不算空，用法：{{ default "tun0" .X }}
+func defaultValue(def, v interface{}) interface{} {
+	if v == nil || reflect.ValueOf(v).IsZero() {
+		return def
+	}
+	return v
+}
+
+// templateFuncs 构造模板辅助函数，seed 用于 randomPort 的稳定随机，同一模拟器多次渲染结果一致
+func templateFuncs(seed string) template.FuncMap {
+	return template.FuncMap{
+		// json 输出 JSON 编码后的值，用于 JSON 模板中安全地写入字符串
+		"json":      jsonString,
+		"quote":     quote,
+		"squote":    squote,
+		"dquote":    dquote,
+		"base64":    func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"base64url": func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) },
+		"urlquery":  url.QueryEscape,
+		"default":   defaultValue,
+		"join":      strings.Join,
+		"lower":     strings.ToLower,
+		"upper":     strings.ToUpper,
+		"trim":      strings.TrimSpace,
+		"add":       func(a, b int) int { return a + b },
+		// randomPort 在 [min, max] 内为当前模拟器生成稳定端口，key 用于区分同一模板中的多个端口
+		"randomPort": func(min, max int, key ...string) (int, error) {
+			if min <= 0 || max > 65535 || min > max {
+				return 0, fmt.Errorf("randomPort 端口范围非法: %d-%d", min, max)
+			}
+			h := fnv.New32a()
+			_, _ = h.Write([]byte(seed + "|" + strings.Join(key, "|")))
+			return min + int(h.Sum32()%uint32(max-min+1)), nil
+		},
+	}
+}
@@ -0,0 +1,33 @@
+package subscribe
+
+import (
+	"strings"
+
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+// matchGeo 代理的地理位置是否满足模拟器的出口地区要求，未配置要求时总是满足
+func matchGeo(proxy *models.Proxy, emulator *models.Emulator) bool {
+	if emulator.Country != "" && !strings.EqualFold(proxy.Country, emulator.Country) {
+		return false
+	}
+	if emulator.Region != "" && !strings.EqualFold(proxy.Region, emulator.Region) {
+		return false
+	}
+	return true
+}
+
+// filterGeo 过滤出满足模拟器出口地区要求的代理
+func filterGeo(proxies []models.Proxy, emulator *models.Emulator) []models.Proxy {
+	if !emulator.GeoConstrained() {
+		return proxies
+	}
+
+	result := make([]models.Proxy, 0, len(proxies))
+	for i := range proxies {
+		if matchGeo(&proxies[i], emulator) {
+			result = append(result, proxies[i])
+		}
+	}
+	return result
+}
@@ -0,0 +1,40 @@
+package subscribe
+
+import (
+	"testing"
+
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+func TestFilterGeo(t *testing.T) {
+	proxies := []models.Proxy{
+		{IP: "10.0.0.1", Country: "DE", Region: "Bavaria"},
+		{IP: "10.0.0.2", Country: "DE", Region: "Berlin"},
+		{IP: "10.0.0.3", Country: "US", Region: "New York"},
+		{IP: "10.0.0.4"}, // 未收录地理信息
+	}
+
+	cases := []struct {
+		name     string
+		emulator models.Emulator
+		want     []string
+	}{
+		{"不限制", models.Emulator{}, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4"}},
+		{"国家", models.Emulator{Country: "de"}, []string{"10.0.0.1", "10.0.0.2"}},
+		{"国家和地区", models.Emulator{Country: "DE", Region: "berlin"}, []string{"10.0.0.2"}},
+		{"仅地区", models.Emulator{Region: "New York"}, []string{"10.0.0.3"}},
+		{"无匹配", models.Emulator{Country: "FR"}, nil},
+	}
+	for _, c := range cases {
+		got := filterGeo(proxies, &c.emulator)
+		if len(got) != len(c.want) {
+			t.Errorf("%s: got %d proxies, want %v", c.name, len(got), c.want)
+			continue
+		}
+		for i, p := range got {
+			if p.IP != c.want[i] {
+				t.Errorf("%s: got %s at %d, want %s", c.name, p.IP, i, c.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package subscribe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+// 订阅响应头
+const (
+	HeaderSubscriptionUserinfo  = "Subscription-Userinfo"
+	HeaderProfileUpdateInterval = "Profile-Update-Interval"
+	HeaderContentDisposition    = "Content-Disposition"
+)
+
+// computeETag 根据渲染后的配置内容生成强 ETag
+func computeETag(cfg string) string {
+	sum := sha256.Sum256([]byte(cfg))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatch 判断 If-None-Match 是否命中 etag，支持逗号分隔的多个值、弱校验前缀 W/ 以及 *
+func etagMatch(ifNoneMatch, etag string) bool {
+	for _, v := range strings.Split(ifNoneMatch, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" || strings.TrimPrefix(v, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// groupHeaders 根据分组配置生成订阅响应头，未配置的项不下发
+func groupHeaders(group *models.Groups) map[string]string {
+	headers := make(map[string]string)
+	if group.SubscriptionUserinfo != "" {
+		headers[HeaderSubscriptionUserinfo] = group.SubscriptionUserinfo
+	}
+	if group.ProfileUpdateInterval > 0 {
+		headers[HeaderProfileUpdateInterval] = strconv.Itoa(group.ProfileUpdateInterval)
+	}
+	if group.ProfileFilename != "" {
+		// filename* 支持非 ASCII 文件名，filename 作为旧客户端的兜底
+		headers[HeaderContentDisposition] = fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`,
+			asciiFilename(group.ProfileFilename), url.PathEscape(group.ProfileFilename))
+	}
+	return headers
+}
+
+// asciiFilename 将非 ASCII 及引号字符替换为下划线
+func asciiFilename(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r > 0x7e || r < 0x20 || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, name)
+}
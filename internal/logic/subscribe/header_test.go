@@ -0,0 +1,50 @@
+package subscribe
+
+import (
+	"testing"
+
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+func TestETagMatch(t *testing.T) {
+	etag := computeETag("proxies: []")
+	if etag != computeETag("proxies: []") {
+		t.Fatal("相同内容的 ETag 应一致")
+	}
+
+	cases := map[string]bool{
+		etag:                      true,
+		"W/" + etag:               true,
+		`"other", ` + etag:        true,
+		"*":                       true,
+		`"other"`:                 false,
+		computeETag("proxies: 1"): false,
+	}
+	for header, want := range cases {
+		if got := etagMatch(header, etag); got != want {
+			t.Errorf("etagMatch(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
+
+func TestGroupHeaders(t *testing.T) {
+	if h := groupHeaders(&models.Groups{}); len(h) != 0 {
+		t.Fatalf("未配置时不应下发响应头: %v", h)
+	}
+
+	h := groupHeaders(&models.Groups{
+		ProfileUpdateInterval: 12,
+		ProfileFilename:       "住宅代理.yaml",
+		SubscriptionUserinfo:  "upload=0; download=0; total=0; expire=0",
+	})
+	if h[HeaderProfileUpdateInterval] != "12" {
+		t.Errorf("profile-update-interval: %q", h[HeaderProfileUpdateInterval])
+	}
+	if h[HeaderSubscriptionUserinfo] != "upload=0; download=0; total=0; expire=0" {
+		t.Errorf("subscription-userinfo: %q", h[HeaderSubscriptionUserinfo])
+	}
+	want := `attachment; filename="____.yaml"; filename*=UTF-8''%E4%BD%8F%E5%AE%85%E4%BB%A3%E7%90%86.yaml`
+	if h[HeaderContentDisposition] != want {
+		t.Errorf("content-disposition: %q", h[HeaderContentDisposition])
+	}
+}
@@ -0,0 +1,55 @@
+package subscribe
+
+import (
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+// historyIPs 查询模拟器在分组历史窗口内用过的IP，按最近使用排序；分组未启用历史IP策略时返回 nil
+func (s *Svc) historyIPs(emulator *models.Emulator, group *models.Groups, now int64) ([]string, error) {
+	since := group.HistorySince(now)
+	if since == 0 {
+		return nil, nil
+	}
+	return s.getBindingHistoryRepo().ListRecentIPs(emulator.UUID, since)
+}
+
+// filterHistory 排除模拟器用过的IP
+func filterHistory(proxies []models.Proxy, history []string) []models.Proxy {
+	if len(history) == 0 {
+		return proxies
+	}
+
+	used := make(map[string]bool, len(history))
+	for _, ip := range history {
+		used[ip] = true
+	}
+	result := make([]models.Proxy, 0, len(proxies))
+	for _, p := range proxies {
+		if !used[p.IP] {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// affinitySelector 在所有未满载的代理中（不限优先级）查找模拟器用过的IP（当前IP除外），选最近用过的一个，否则交给 next
+type affinitySelector struct {
+	history   []string
+	maxOnline int64
+	next      Selector
+}
+
+func (s *affinitySelector) Select(candidates []models.Proxy, emulator *models.Emulator) *models.Proxy {
+	available := filterAvailable(candidates, s.maxOnline)
+	for _, ip := range s.history {
+		if ip == emulator.IP {
+			continue
+		}
+		for i := range available {
+			if available[i].IP == ip {
+				return &available[i]
+			}
+		}
+	}
+	return s.next.Select(candidates, emulator)
+}
@@ -0,0 +1,79 @@
+package subscribe
+
+import (
+	"testing"
+
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+func TestFilterHistory(t *testing.T) {
+	proxies := testProxies()
+	got := filterHistory(proxies, []string{"10.0.0.1", "10.0.0.4"})
+	if len(got) != 2 || got[0].IP != "10.0.0.3" || got[1].IP != "10.0.0.2" {
+		t.Errorf("got %v, want 10.0.0.3 and 10.0.0.2", got)
+	}
+	if got := filterHistory(proxies, nil); len(got) != len(proxies) {
+		t.Errorf("empty history should keep all proxies, got %d", len(got))
+	}
+}
+
+func TestAffinitySelector(t *testing.T) {
+	next := &leastUsedSelector{intn: func(n int) int { return 0 }}
+	s := &affinitySelector{history: []string{"10.0.0.9", "10.0.0.2", "10.0.0.3"}, maxOnline: 3, next: next}
+
+	// 最近用过且在候选中的IP优先，不在候选中的（如已满载被排除）跳过
+	got := s.Select(testProxies(), &models.Emulator{})
+	if got.IP != "10.0.0.2" {
+		t.Errorf("got %s, want 10.0.0.2", got.IP)
+	}
+
+	// 当前IP不算回归，继续找下一个历史IP
+	got = s.Select(testProxies(), &models.Emulator{IP: "10.0.0.2"})
+	if got.IP != "10.0.0.3" {
+		t.Errorf("got %s, want 10.0.0.3", got.IP)
+	}
+
+	// 候选中没有历史IP时交给下一个选择器
+	got = s.Select([]models.Proxy{{IP: "10.0.0.1"}, {IP: "10.0.0.4", InUseCount: 1}}, &models.Emulator{})
+	if got.IP != "10.0.0.1" {
+		t.Errorf("got %s, want 10.0.0.1", got.IP)
+	}
+
+	// 历史IP不限优先级，低优先级层中未满载的历史IP也会被选回；已满载的历史IP跳过
+	tiered := &tieredSelector{maxOnline: 2, next: next}
+	s = &affinitySelector{history: []string{"10.0.0.5", "10.0.0.6"}, maxOnline: 2, next: tiered}
+	proxies := []models.Proxy{
+		{IP: "10.0.0.1", Priority: 2},
+		{IP: "10.0.0.5", Priority: 1, InUseCount: 2},
+		{IP: "10.0.0.6", Priority: 0, InUseCount: 1},
+	}
+	if got := s.Select(proxies, &models.Emulator{}); got.IP != "10.0.0.6" {
+		t.Errorf("got %s, want 10.0.0.6", got.IP)
+	}
+
+	// 没有未满载的历史IP时按优先级分层后交给选择策略
+	proxies[2].InUseCount = 2
+	if got := s.Select(proxies, &models.Emulator{}); got.IP != "10.0.0.1" {
+		t.Errorf("got %s, want 10.0.0.1", got.IP)
+	}
+}
+
+func TestHistorySince(t *testing.T) {
+	now := int64(10 * 86400)
+	cases := []struct {
+		policy string
+		days   int
+		want   int64
+	}{
+		{models.HistoryNone, 3, 0},
+		{models.HistoryAvoid, 0, 0},
+		{models.HistoryAvoid, 3, 7 * 86400},
+		{models.HistoryAffinity, 1, 9 * 86400},
+	}
+	for _, c := range cases {
+		g := &models.Groups{HistoryPolicy: c.policy, HistoryDays: c.days}
+		if got := g.HistorySince(now); got != c.want {
+			t.Errorf("%s/%d: got %d, want %d", c.policy, c.days, got, c.want)
+		}
+	}
+}
@@ -1,60 +1,195 @@
 package subscribe
 
 import (
-	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"text/template"
 
 	"github.com/maxliu9403/ProxyHub/models"
+	"github.com/maxliu9403/common/logger"
 )
 
+// primaryProxyName 当前绑定代理在配置中的名称
+const primaryProxyName = "residential"
+
+// ProxyData 模板中的单个代理
+type ProxyData struct {
+	Name     string
+	Protocol string
+	Server   string
+	Port     int
+	Username string
+	Password string
+	Extra    map[string]interface{} // 协议相关参数，如 ss 的 cipher、vmess 的 alterId
+}
+
+// EmulatorData 模板中的模拟器信息
+type EmulatorData struct {
+	UUID      string
+	BrowserID string
+}
+
+// GroupData 模板中的分组信息
+type GroupData struct {
+	ID         int64
+	Name       string
+	BackupType string // 备用代理组类型 fallback/url-test
+}
+
 type ClashTemplateData struct {
+	// ISP* 为当前绑定代理，保留以兼容旧模板，新模板建议使用 .Proxy
 	ISPProtocol string
 	ISPServer   string
 	ISPPort     int
 	ISPUsername string
 	ISPPassword string
+
+	Proxy    ProxyData   // 当前绑定代理
+	Backups  []ProxyData // 备用代理
+	Emulator EmulatorData
+	Group    GroupData
+	Token    string
 }
 
-func loadTemplate() ([]byte, error) {
-	execPath, err := os.Executable()
-	if err != nil {
-		return nil, err
+// Proxies 返回当前代理及全部备用代理，便于模板中统一遍历
+func (d *ClashTemplateData) Proxies() []ProxyData {
+	return append([]ProxyData{d.Proxy}, d.Backups...)
+}
+
+func newProxyData(name string, proxy *models.Proxy) ProxyData {
+	return ProxyData{
+		Name:     name,
+		Protocol: proxy.ProxyType,
+		Server:   proxy.IP,
+		Port:     int(proxy.Port),
+		Username: proxy.Username,
+		Password: proxy.Password,
+		Extra:    proxyExtra(proxy),
 	}
-	execDir := filepath.Dir(execPath)
-	templatePath := filepath.Join(execDir, "configs", "base_proxy.yaml")
-	return os.ReadFile(templatePath)
 }
 
-func (s *Svc) renderClashConfig(proxy *models.Proxy) (string, error) {
-	// 准备数据
-	data := ClashTemplateData{
+// proxyExtra 解析代理的协议参数，写入时已校验为 JSON，解析失败按无参数处理
+func proxyExtra(proxy *models.Proxy) map[string]interface{} {
+	extra := make(map[string]interface{})
+	if proxy.Extra != "" {
+		_ = json.Unmarshal([]byte(proxy.Extra), &extra)
+	}
+	return extra
+}
+
+// NewTemplateData 根据代理构造模板数据
+func NewTemplateData(proxy *models.Proxy) *ClashTemplateData {
+	return &ClashTemplateData{
 		ISPProtocol: proxy.ProxyType, // 示例字段
 		ISPServer:   proxy.IP,
 		ISPPort:     int(proxy.Port),
 		ISPUsername: proxy.Username,
 		ISPPassword: proxy.Password,
+		Proxy:       newProxyData(primaryProxyName, proxy),
+		Backups:     []ProxyData{},
+	}
+}
+
+// SetEmulator 填充模拟器信息
+func (d *ClashTemplateData) SetEmulator(emulator *models.Emulator) *ClashTemplateData {
+	d.Emulator = EmulatorData{UUID: emulator.UUID, BrowserID: emulator.BrowserID}
+	return d
+}
+
+// SetGroup 填充分组信息
+func (d *ClashTemplateData) SetGroup(group *models.Groups) *ClashTemplateData {
+	d.Group = GroupData{ID: group.ID, Name: group.Name, BackupType: group.BackupType}
+	if d.Group.BackupType == "" {
+		d.Group.BackupType = models.BackupTypeFallback
 	}
+	return d
+}
 
-	// 读取模板
-	tmplContent, err := loadTemplate()
+// SampleTemplateData 示例数据，用于模板预览和保存前校验
+func SampleTemplateData() *ClashTemplateData {
+	data := NewTemplateData(&models.Proxy{
+		IP:        "203.0.113.10",
+		Port:      1080,
+		Username:  "sample-user",
+		Password:  "sample-pass",
+		ProxyType: "socks5",
+	})
+	data.Emulator = EmulatorData{UUID: "00000000-0000-0000-0000-000000000000", BrowserID: "sample-browser"}
+	data.Group = GroupData{ID: 1, Name: "sample-group", BackupType: models.BackupTypeFallback}
+	data.Token = "sample-token"
+	data.Backups = append(data.Backups, newProxyData("backup-1", &models.Proxy{
+		IP:        "203.0.113.11",
+		Port:      1080,
+		Username:  "sample-user",
+		Password:  "sample-pass",
+		ProxyType: "socks5",
+	}))
+	return data
+}
+
+// Render 使用指定模板内容渲染，content 为空时使用默认模板
+func Render(format Format, content string, data *ClashTemplateData) (string, error) {
+	renderer, err := GetRenderer(format)
+	if err != nil {
+		return "", err
+	}
+	return renderer.Render(content, data)
+}
+
+func loadTemplate(name string) ([]byte, error) {
+	execPath, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	execDir := filepath.Dir(execPath)
+	templatePath := filepath.Join(execDir, "configs", name)
+	return os.ReadFile(templatePath)
+}
+
+// resolveTemplate 查找模拟器生效的模板内容，未绑定时返回空串表示使用默认模板
+func (s *Svc) resolveTemplate(emulator *models.Emulator, format Format) (string, error) {
+	if !SupportsTemplate(format) {
+		return "", nil
+	}
+
+	t, err := s.getTemplateRepo().Resolve(emulator.GroupID, emulator.UUID, string(format))
 	if err != nil {
-		return "", fmt.Errorf("读取模板失败: %w", err)
+		return "", fmt.Errorf("查询模板绑定失败: %w", err)
 	}
+	if t == nil {
+		return "", nil
+	}
+
+	logger.InfofWithTrace(s.Ctx, "模拟器 %s 使用模板 %s(v%d)", emulator.UUID, t.Name, t.Version)
+	return t.Content, nil
+}
 
-	// 创建模板
-	tmpl, err := template.New("clash").Parse(string(tmplContent))
+// renderConfig 渲染订阅配置，record 为 false 时只读，不记录备用代理
+func (s *Svc) renderConfig(format Format, token string, emulator *models.Emulator, group *models.Groups, proxy *models.Proxy, record bool) (string, error) {
+	content, err := s.resolveTemplate(emulator, format)
 	if err != nil {
-		return "", fmt.Errorf("模板解析失败: %w", err)
+		return "", err
 	}
 
-	// 渲染模板
-	var rendered bytes.Buffer
-	if err := tmpl.Execute(&rendered, data); err != nil {
-		return "", fmt.Errorf("模板渲染失败: %w", err)
+	data, err := s.templateData(token, emulator, group, proxy, record)
+	if err != nil {
+		return "", err
 	}
+	return Render(format, content, data)
+}
+
+// PreviewData 按订阅渲染的方式构造模板数据（含备用代理），只读，供模板预览使用
+func (s *Svc) PreviewData(token string, emulator *models.Emulator, group *models.Groups, proxy *models.Proxy) (*ClashTemplateData, error) {
+	return s.templateData(token, emulator, group, proxy, false)
+}
 
-	return rendered.String(), nil
+// templateData 构造订阅的模板数据并附加备用代理，record 含义同 attachBackups
+func (s *Svc) templateData(token string, emulator *models.Emulator, group *models.Groups, proxy *models.Proxy, record bool) (*ClashTemplateData, error) {
+	data := NewTemplateData(proxy).SetEmulator(emulator).SetGroup(group)
+	data.Token = token
+	if err := s.attachBackups(data, emulator, group, record); err != nil {
+		return nil, err
+	}
+	return data, nil
 }
@@ -0,0 +1,83 @@
+package subscribe
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// Renderer 将模板数据渲染为某一客户端可识别的订阅内容
+type Renderer interface {
+	ContentType() string
+	// Render content 为数据库中解析出的模板内容，为空时使用默认模板
+	Render(content string, data *ClashTemplateData) (string, error)
+}
+
+// templateRenderer 基于模板渲染，未指定模板时使用 configs 目录下的默认模板文件
+type templateRenderer struct {
+	name        string // 模板名，同时用于错误信息
+	file        string // 模板文件名
+	contentType string
+}
+
+func (r *templateRenderer) ContentType() string {
+	return r.contentType
+}
+
+func (r *templateRenderer) Render(content string, data *ClashTemplateData) (string, error) {
+	// 读取默认模板
+	if content == "" {
+		tmplContent, err := loadTemplate(r.file)
+		if err != nil {
+			return "", fmt.Errorf("读取模板失败: %w", err)
+		}
+		content = string(tmplContent)
+	}
+
+	// 创建模板
+	tmpl, err := template.New(r.name).Funcs(templateFuncs(data.Emulator.UUID)).Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("模板解析失败: %w", err)
+	}
+
+	// 渲染模板
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", fmt.Errorf("模板渲染失败: %w", err)
+	}
+
+	return rendered.String(), nil
+}
+
+const (
+	contentTypeYAML  = "application/yaml"
+	contentTypeJSON  = "application/json; charset=utf-8"
+	contentTypePlain = "text/plain; charset=utf-8"
+)
+
+// renderers 各订阅格式对应的渲染器
+var renderers = map[Format]Renderer{
+	FormatClash:        &templateRenderer{name: "clash", file: "base_proxy.yaml", contentType: contentTypeYAML},
+	FormatSingBox:      &templateRenderer{name: "singbox", file: "sing_box.json", contentType: contentTypeJSON},
+	FormatSurge:        &templateRenderer{name: "surge", file: "surge.conf", contentType: contentTypePlain},
+	FormatShadowrocket: &templateRenderer{name: "shadowrocket", file: "shadowrocket.conf", contentType: contentTypePlain},
+	FormatQuantumultX:  &templateRenderer{name: "quanx", file: "quantumult_x.conf", contentType: contentTypePlain},
+	FormatLoon:         &templateRenderer{name: "loon", file: "loon.conf", contentType: contentTypePlain},
+	FormatXray:         &templateRenderer{name: "xray", file: "xray.json", contentType: contentTypeJSON},
+	FormatURIList:      &uriListRenderer{},
+}
+
+// GetRenderer 获取格式对应的渲染器
+func GetRenderer(f Format) (Renderer, error) {
+	r, ok := renderers[f]
+	if !ok {
+		return nil, fmt.Errorf("不支持的订阅格式: %s", f)
+	}
+	return r, nil
+}
+
+// SupportsTemplate 判断格式是否支持自定义模板
+func SupportsTemplate(f Format) bool {
+	_, ok := renderers[f].(*templateRenderer)
+	return ok
+}
@@ -0,0 +1,28 @@
+package subscribe
+
+import (
+	"time"
+
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+// shouldRotate 根据分组轮换策略判断本次订阅是否需要切换 IP
+// requested 为调用方显式要求切换（如 rotate=1），对所有策略均生效
+func shouldRotate(group *models.Groups, emulator *models.Emulator, requested bool, now time.Time) bool {
+	if requested || emulator.IP == "" {
+		return true
+	}
+
+	switch group.RotationPolicy {
+	case models.RotationAlways:
+		return true
+	case models.RotationSticky:
+		// 升级前已存在的绑定没有开始时间，视为从现在开始计时，不立即切换
+		if emulator.BoundAt == 0 {
+			return false
+		}
+		return now.Unix()-emulator.BoundAt >= group.StickyTTL
+	default:
+		return false
+	}
+}
@@ -0,0 +1,62 @@
+package subscribe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+func TestShouldRotate(t *testing.T) {
+	now := time.Unix(10000, 0)
+	bound := &models.Emulator{IP: "10.0.0.1", BoundAt: 9000}
+
+	cases := []struct {
+		name      string
+		group     models.Groups
+		emulator  *models.Emulator
+		requested bool
+		want      bool
+	}{
+		{"未绑定", models.Groups{RotationPolicy: models.RotationOnDemand}, &models.Emulator{}, false, true},
+		{"always", models.Groups{RotationPolicy: models.RotationAlways}, bound, false, true},
+		{"on_demand 未请求", models.Groups{RotationPolicy: models.RotationOnDemand}, bound, false, false},
+		{"on_demand 显式请求", models.Groups{RotationPolicy: models.RotationOnDemand}, bound, true, true},
+		{"sticky 未过期", models.Groups{RotationPolicy: models.RotationSticky, StickyTTL: 3600}, bound, false, false},
+		{"sticky 已过期", models.Groups{RotationPolicy: models.RotationSticky, StickyTTL: 600}, bound, false, true},
+		{"sticky 无绑定时间", models.Groups{RotationPolicy: models.RotationSticky, StickyTTL: 600}, &models.Emulator{IP: "10.0.0.1"}, false, false},
+		{"sticky 显式请求", models.Groups{RotationPolicy: models.RotationSticky, StickyTTL: 3600}, bound, true, true},
+	}
+	for _, c := range cases {
+		if got := shouldRotate(&c.group, c.emulator, c.requested, now); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+// 需要切换或重新绑定的请求不能走条件请求流程，即使 If-None-Match 为 *
+func TestConditional(t *testing.T) {
+	now := time.Unix(10000, 0)
+	bound := &models.Emulator{IP: "10.0.0.1", BoundAt: 9000}
+	onDemand := &models.Groups{RotationPolicy: models.RotationOnDemand}
+
+	cases := []struct {
+		name     string
+		opts     SubscribeOptions
+		group    *models.Groups
+		emulator *models.Emulator
+		want     bool
+	}{
+		{"沿用绑定", SubscribeOptions{IfNoneMatch: `"abc"`}, onDemand, bound, true},
+		{"无 If-None-Match", SubscribeOptions{}, onDemand, bound, false},
+		{"rotate=1", SubscribeOptions{IfNoneMatch: "*", Rotate: true}, onDemand, bound, false},
+		{"always", SubscribeOptions{IfNoneMatch: "*"}, &models.Groups{RotationPolicy: models.RotationAlways}, bound, false},
+		{"sticky 已过期", SubscribeOptions{IfNoneMatch: "*"}, &models.Groups{RotationPolicy: models.RotationSticky, StickyTTL: 600}, bound, false},
+		{"未绑定", SubscribeOptions{IfNoneMatch: "*"}, onDemand, &models.Emulator{}, false},
+	}
+	for _, c := range cases {
+		if got := conditional(c.opts, c.group, c.emulator, now); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -1,36 +1,288 @@
 package subscribe
 
 import (
+	"math"
 	"math/rand"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/cespare/xxhash/v2"
 	"github.com/maxliu9403/ProxyHub/models"
 )
 
-// 选负载最低
-func selectLeastUsedProxies(proxies []models.Proxy, maxOnline int64) []models.Proxy {
-	var candidates []models.Proxy
-	minCount := maxOnline + 1
+// Selector 代理选择策略，由分组的 SelectStrategy 决定
+type Selector interface {
+	// Select 从候选代理中为模拟器选出一个代理，候选为空时返回 nil
+	Select(candidates []models.Proxy, emulator *models.Emulator) *models.Proxy
+}
+
+// NewSelector 根据分组配置创建代理选择策略，未知策略按 least_used 处理
+func NewSelector(group *models.Groups) Selector {
+	switch group.SelectStrategy {
+	case models.SelectWeightedRandom:
+		return &weightedRandomSelector{maxOnline: int64(group.MaxOnline), intn: randIntn}
+	case models.SelectRoundRobin:
+		return &roundRobinSelector{groupID: group.ID, counters: defaultRoundRobinCounters}
+	case models.SelectConsistentHash:
+		return &consistentHashSelector{}
+	case models.SelectLowLatency:
+		return &lowLatencySelector{intn: randIntn}
+	default:
+		return &leastUsedSelector{intn: randIntn}
+	}
+}
+
+// 全局随机源，math/rand 的 Rand 非并发安全，需加锁
+var (
+	rngMu sync.Mutex
+	rng   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+func randIntn(n int) int {
+	rngMu.Lock()
+	defer rngMu.Unlock()
+	return rng.Intn(n)
+}
+
+// leastUsedSelector 在负载最低的代理中按权重随机选择
+type leastUsedSelector struct {
+	intn func(n int) int
+}
+
+func (s *leastUsedSelector) Select(candidates []models.Proxy, emulator *models.Emulator) *models.Proxy {
+	pool := preferOthers(leastUsed(candidates), emulator.IP)
+	if len(pool) == 0 {
+		return nil
+	}
+
+	weights := make([]int64, len(pool))
+	for i := range pool {
+		weights[i] = proxyWeight(&pool[i])
+	}
+	return pickWeighted(pool, weights, s.intn)
+}
+
+// weightedRandomSelector 按 权重 × 剩余容量 加权随机，剩余容量越大越容易被选中；全部满载时仅按权重随机
+type weightedRandomSelector struct {
+	maxOnline int64
+	intn      func(n int) int
+}
+
+func (s *weightedRandomSelector) Select(candidates []models.Proxy, emulator *models.Emulator) *models.Proxy {
+	pool := preferOthers(candidates, emulator.IP)
+	if len(pool) == 0 {
+		return nil
+	}
+
+	var total int64
+	weights := make([]int64, len(pool))
+	for i := range pool {
+		if free := s.maxOnline - pool[i].InUseCount; free > 0 {
+			weights[i] = proxyWeight(&pool[i]) * free
+			total += weights[i]
+		}
+	}
+	if total == 0 {
+		for i := range pool {
+			weights[i] = proxyWeight(&pool[i])
+		}
+	}
+	return pickWeighted(pool, weights, s.intn)
+}
 
+// roundRobinCounters 按分组记录轮询位置，仅在进程内生效
+type roundRobinCounters struct {
+	mu    sync.Mutex
+	count map[int64]uint64
+}
+
+var defaultRoundRobinCounters = &roundRobinCounters{count: make(map[int64]uint64)}
+
+func (c *roundRobinCounters) next(groupID int64) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := c.count[groupID]
+	c.count[groupID] = n + 1
+	return n
+}
+
+// roundRobinSelector 按 IP 排序后在分组内依次轮询，每个代理连续被选中的次数等于其权重
+type roundRobinSelector struct {
+	groupID  int64
+	counters *roundRobinCounters
+}
+
+func (s *roundRobinSelector) Select(candidates []models.Proxy, emulator *models.Emulator) *models.Proxy {
+	pool := preferOthers(candidates, emulator.IP)
+	if len(pool) == 0 {
+		return nil
+	}
+
+	sorted := make([]models.Proxy, len(pool))
+	copy(sorted, pool)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].IP < sorted[j].IP })
+
+	var total uint64
+	for i := range sorted {
+		total += uint64(proxyWeight(&sorted[i]))
+	}
+	pos := s.counters.next(s.groupID) % total
+	for i := range sorted {
+		w := uint64(proxyWeight(&sorted[i]))
+		if pos < w {
+			return &sorted[i]
+		}
+		pos -= w
+	}
+	return &sorted[len(sorted)-1]
+}
+
+// consistentHashSelector 按模拟器 UUID 做加权一致性哈希（weighted rendezvous），
+// 同一模拟器在代理池不变时总是落到同一代理，代理增减时只影响原本落在该代理上的模拟器；
+// 需要更换时排除当前 IP，落到该模拟器排序中的下一个代理
+type consistentHashSelector struct{}
+
+func (s *consistentHashSelector) Select(candidates []models.Proxy, emulator *models.Emulator) *models.Proxy {
+	candidates = preferOthers(candidates, emulator.IP)
+	var (
+		best      *models.Proxy
+		bestScore float64
+	)
+	for i := range candidates {
+		// 将哈希值映射到 (0,1)，score = w / -ln(u)，权重越大得分越高
+		h := xxhash.Sum64String(emulator.UUID + "|" + candidates[i].IP)
+		u := (float64(h>>11) + 0.5) / (1 << 53)
+		score := float64(proxyWeight(&candidates[i])) / -math.Log(u)
+		if best == nil || score > bestScore {
+			best, bestScore = &candidates[i], score
+		}
+	}
+	return best
+}
+
+// 延迟容差：延迟不超过 最低延迟×(1+latencyTolerance) 或 最低延迟+latencySlackMs 的代理视为同一档
+const (
+	latencyTolerance = 0.2
+	latencySlackMs   = 10
+)
+
+// lowLatencySelector 优先选择健康检测延迟低的代理，在最低延迟档内按权重随机以免所有模拟器挤到同一代理；
+// 延迟未知（未检测）的代理仅在没有已知延迟的代理时参与选择
+type lowLatencySelector struct {
+	intn func(n int) int
+}
+
+func (s *lowLatencySelector) Select(candidates []models.Proxy, emulator *models.Emulator) *models.Proxy {
+	pool := preferOthers(candidates, emulator.IP)
+	if len(pool) == 0 {
+		return nil
+	}
+
+	var best int64
+	for _, p := range pool {
+		if p.LatencyMs > 0 && (best == 0 || p.LatencyMs < best) {
+			best = p.LatencyMs
+		}
+	}
+
+	limit := best + latencySlackMs
+	if byRatio := int64(float64(best) * (1 + latencyTolerance)); byRatio > limit {
+		limit = byRatio
+	}
+	var tier []models.Proxy
+	for _, p := range pool {
+		if best == 0 || (p.LatencyMs > 0 && p.LatencyMs <= limit) {
+			tier = append(tier, p)
+		}
+	}
+
+	weights := make([]int64, len(tier))
+	for i := range tier {
+		weights[i] = proxyWeight(&tier[i])
+	}
+	return pickWeighted(tier, weights, s.intn)
+}
+
+// pickWeighted 按权重随机选择，权重总和为 0 时等概率随机
+func pickWeighted(pool []models.Proxy, weights []int64, intn func(n int) int) *models.Proxy {
+	var total int64
+	for _, w := range weights {
+		total += w
+	}
+	if total <= 0 {
+		return &pool[intn(len(pool))]
+	}
+
+	r := int64(intn(int(total)))
+	for i, w := range weights {
+		if r < w {
+			return &pool[i]
+		}
+		r -= w
+	}
+	return &pool[len(pool)-1]
+}
+
+// proxyWeight 返回代理权重，未设置时按 1 处理
+func proxyWeight(p *models.Proxy) int64 {
+	if p.Weight <= 0 {
+		return 1
+	}
+	return int64(p.Weight)
+}
+
+// tieredSelector 先按 tierCandidates 取出优先级最高的一层，再交给 next 选择
+type tieredSelector struct {
+	maxOnline int64
+	next      Selector
+}
+
+func (s *tieredSelector) Select(candidates []models.Proxy, emulator *models.Emulator) *models.Proxy {
+	tier, _ := tierCandidates(candidates, s.maxOnline)
+	if len(tier) == 0 {
+		return nil
+	}
+	return s.next.Select(tier, emulator)
+}
+
+// tierCandidates 返回本次参与选择的候选：优先取未满载代理中优先级最高的一层，
+// 全部满载时（overflow 为 true）取所有代理中优先级最高的一层
+func tierCandidates(proxies []models.Proxy, maxOnline int64) (candidates []models.Proxy, overflow bool) {
+	if available := filterAvailable(proxies, maxOnline); len(available) > 0 {
+		return topPriority(available), false
+	}
+	return topPriority(proxies), true
+}
+
+// topPriority 返回优先级最高的代理列表
+func topPriority(proxies []models.Proxy) []models.Proxy {
+	var result []models.Proxy
 	for _, p := range proxies {
-		if p.InUseCount >= maxOnline {
-			continue
+		if len(result) == 0 || p.Priority > result[0].Priority {
+			result = []models.Proxy{p}
+		} else if p.Priority == result[0].Priority {
+			result = append(result, p)
 		}
-		if p.InUseCount < minCount {
-			minCount = p.InUseCount
+	}
+	return result
+}
+
+// leastUsed 返回使用数最小的代理列表
+func leastUsed(proxies []models.Proxy) []models.Proxy {
+	var candidates []models.Proxy
+	for _, p := range proxies {
+		if len(candidates) == 0 || p.InUseCount < candidates[0].InUseCount {
 			candidates = []models.Proxy{p}
-		} else if p.InUseCount == minCount {
+		} else if p.InUseCount == candidates[0].InUseCount {
 			candidates = append(candidates, p)
 		}
 	}
 	return candidates
 }
 
-// 随机选择
-func pickRandomProxy(candidates []models.Proxy, currentIP string) *models.Proxy {
-	rand.Seed(time.Now().UnixNano())
-
-	// 首先尝试从非当前IP中选
+// preferOthers 优先排除当前 IP，若只剩当前 IP 则只能重用
+func preferOthers(candidates []models.Proxy, currentIP string) []models.Proxy {
 	var altProxies []models.Proxy
 	for _, p := range candidates {
 		if p.IP != currentIP {
@@ -38,11 +290,36 @@ func pickRandomProxy(candidates []models.Proxy, currentIP string) *models.Proxy
 		}
 	}
 	if len(altProxies) > 0 {
-		return &altProxies[rand.Intn(len(altProxies))]
+		return altProxies
 	}
+	return candidates
+}
 
-	// 若都与当前IP重复，只能重用当前
-	return &candidates[rand.Intn(len(candidates))]
+// filterAvailable 过滤出未超过最大在线数的代理
+func filterAvailable(proxies []models.Proxy, maxOnline int64) []models.Proxy {
+	var result []models.Proxy
+	for _, p := range proxies {
+		if p.InUseCount < maxOnline {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// 选负载最低
+func selectLeastUsedProxies(proxies []models.Proxy, maxOnline int64) []models.Proxy {
+	return leastUsed(filterAvailable(proxies, maxOnline))
+}
+
+// filterAssignable 过滤出在 now 时刻可分配给模拟器的代理，冷却期内的代理只保留给最近释放它的模拟器
+func filterAssignable(proxies []models.Proxy, emulator *models.Emulator, now int64) []models.Proxy {
+	var result []models.Proxy
+	for _, p := range proxies {
+		if p.AssignableTo(emulator.UUID, now) {
+			result = append(result, p)
+		}
+	}
+	return result
 }
 
 func filterUntriedProxies(all []models.Proxy, tried map[string]bool) []models.Proxy {
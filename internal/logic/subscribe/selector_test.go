@@ -0,0 +1,313 @@
+package subscribe
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+func testProxies() []models.Proxy {
+	return []models.Proxy{
+		{IP: "10.0.0.3", InUseCount: 1},
+		{IP: "10.0.0.1", InUseCount: 0},
+		{IP: "10.0.0.2", InUseCount: 2},
+		{IP: "10.0.0.4", InUseCount: 0},
+	}
+}
+
+func TestNewSelector(t *testing.T) {
+	cases := map[string]Selector{
+		"":                          &leastUsedSelector{},
+		models.SelectLeastUsed:      &leastUsedSelector{},
+		models.SelectWeightedRandom: &weightedRandomSelector{},
+		models.SelectRoundRobin:     &roundRobinSelector{},
+		models.SelectConsistentHash: &consistentHashSelector{},
+		models.SelectLowLatency:     &lowLatencySelector{},
+	}
+	for strategy, want := range cases {
+		got := NewSelector(&models.Groups{SelectStrategy: strategy})
+		if gotType, wantType := typeName(got), typeName(want); gotType != wantType {
+			t.Errorf("strategy %q: got %s, want %s", strategy, gotType, wantType)
+		}
+	}
+}
+
+func typeName(s Selector) string {
+	switch s.(type) {
+	case *leastUsedSelector:
+		return "least_used"
+	case *weightedRandomSelector:
+		return "weighted_random"
+	case *roundRobinSelector:
+		return "round_robin"
+	case *consistentHashSelector:
+		return "consistent_hash"
+	case *lowLatencySelector:
+		return "low_latency"
+	}
+	return "unknown"
+}
+
+func TestSelectorsEmpty(t *testing.T) {
+	emulator := &models.Emulator{UUID: "u1"}
+	for _, s := range []Selector{
+		&leastUsedSelector{intn: func(int) int { return 0 }},
+		&weightedRandomSelector{maxOnline: 3, intn: func(int) int { return 0 }},
+		&roundRobinSelector{counters: &roundRobinCounters{count: map[int64]uint64{}}},
+		&consistentHashSelector{},
+		&lowLatencySelector{intn: func(int) int { return 0 }},
+	} {
+		if got := s.Select(nil, emulator); got != nil {
+			t.Errorf("%s: 候选为空时应返回 nil，got %s", typeName(s), got.IP)
+		}
+	}
+}
+
+func TestLeastUsedSelector(t *testing.T) {
+	last := func(n int) int { return n - 1 }
+	s := &leastUsedSelector{intn: last}
+
+	got := s.Select(testProxies(), &models.Emulator{})
+	if got.IP != "10.0.0.4" {
+		t.Errorf("got %s, want 10.0.0.4", got.IP)
+	}
+
+	// 优先避开当前 IP
+	got = s.Select(testProxies(), &models.Emulator{IP: "10.0.0.4"})
+	if got.IP != "10.0.0.1" {
+		t.Errorf("got %s, want 10.0.0.1", got.IP)
+	}
+
+	// 只剩当前 IP 时重用
+	got = s.Select([]models.Proxy{{IP: "10.0.0.4"}}, &models.Emulator{IP: "10.0.0.4"})
+	if got.IP != "10.0.0.4" {
+		t.Errorf("got %s, want 10.0.0.4", got.IP)
+	}
+}
+
+func TestWeightedRandomSelector(t *testing.T) {
+	// maxOnline=3 时剩余容量依次为 2,3,1,3，总权重 9
+	cases := map[int]string{
+		0: "10.0.0.3",
+		1: "10.0.0.3",
+		2: "10.0.0.1",
+		4: "10.0.0.1",
+		5: "10.0.0.2",
+		6: "10.0.0.4",
+		8: "10.0.0.4",
+	}
+	for r, want := range cases {
+		r := r
+		s := &weightedRandomSelector{maxOnline: 3, intn: func(n int) int {
+			if n != 9 {
+				t.Fatalf("总权重 got %d, want 9", n)
+			}
+			return r
+		}}
+		if got := s.Select(testProxies(), &models.Emulator{}); got.IP != want {
+			t.Errorf("r=%d: got %s, want %s", r, got.IP, want)
+		}
+	}
+
+	// 全部满载时等概率随机
+	s := &weightedRandomSelector{maxOnline: 1, intn: func(n int) int { return n - 1 }}
+	full := []models.Proxy{{IP: "10.0.0.1", InUseCount: 1}, {IP: "10.0.0.2", InUseCount: 2}}
+	if got := s.Select(full, &models.Emulator{}); got.IP != "10.0.0.2" {
+		t.Errorf("got %s, want 10.0.0.2", got.IP)
+	}
+}
+
+func TestRoundRobinSelector(t *testing.T) {
+	counters := &roundRobinCounters{count: map[int64]uint64{}}
+	g1 := &roundRobinSelector{groupID: 1, counters: counters}
+	g2 := &roundRobinSelector{groupID: 2, counters: counters}
+
+	want := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.1"}
+	for i, ip := range want {
+		if got := g1.Select(testProxies(), &models.Emulator{}); got.IP != ip {
+			t.Errorf("第 %d 次: got %s, want %s", i+1, got.IP, ip)
+		}
+	}
+
+	// 不同分组的轮询位置互不影响
+	if got := g2.Select(testProxies(), &models.Emulator{}); got.IP != "10.0.0.1" {
+		t.Errorf("分组2: got %s, want 10.0.0.1", got.IP)
+	}
+}
+
+func TestConsistentHashSelector(t *testing.T) {
+	s := &consistentHashSelector{}
+	proxies := testProxies()
+
+	// 同一 UUID 在代理池不变时结果稳定，且与候选顺序无关
+	emulator := &models.Emulator{UUID: "emulator-1"}
+	first := s.Select(proxies, emulator).IP
+	reversed := []models.Proxy{proxies[3], proxies[2], proxies[1], proxies[0]}
+	if got := s.Select(reversed, emulator).IP; got != first {
+		t.Errorf("顺序变化后 got %s, want %s", got, first)
+	}
+
+	// 移除其他代理不影响已有分配
+	var others []models.Proxy
+	removed := false
+	for _, p := range proxies {
+		if p.IP != first && !removed {
+			removed = true
+			continue
+		}
+		others = append(others, p)
+	}
+	if got := s.Select(others, emulator).IP; got != first {
+		t.Errorf("移除其他代理后 got %s, want %s", got, first)
+	}
+
+	// 不同 UUID 应分散到多个代理
+	seen := map[string]bool{}
+	for _, uuid := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"} {
+		seen[s.Select(proxies, &models.Emulator{UUID: uuid}).IP] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("10 个 UUID 只落到了 %d 个代理", len(seen))
+	}
+
+	// 需要更换时跳过当前 IP，稳定落到排序中的下一个代理
+	emulator.IP = first
+	next := s.Select(proxies, emulator).IP
+	if next == first {
+		t.Errorf("更换时仍选中当前 IP %s", first)
+	}
+	if got := s.Select(reversed, emulator).IP; got != next {
+		t.Errorf("更换结果不稳定 got %s, want %s", got, next)
+	}
+	if got := s.Select(others, &models.Emulator{UUID: "emulator-1", IP: next}).IP; got == next {
+		t.Errorf("更换时仍选中当前 IP %s", next)
+	}
+
+	// 只剩当前 IP 时只能重用
+	only := []models.Proxy{{IP: first}}
+	if got := s.Select(only, emulator); got == nil || got.IP != first {
+		t.Errorf("只剩当前 IP 时 got %v, want %s", got, first)
+	}
+}
+
+func TestTierCandidates(t *testing.T) {
+	proxies := []models.Proxy{
+		{IP: "10.0.0.1", Priority: 2, InUseCount: 3},
+		{IP: "10.0.0.2", Priority: 2, InUseCount: 3},
+		{IP: "10.0.0.3", Priority: 1, InUseCount: 1},
+		{IP: "10.0.0.4", Priority: 1, InUseCount: 3},
+		{IP: "10.0.0.5", Priority: 0, InUseCount: 0},
+	}
+
+	// 高优先级已满，使用下一层中未满载的代理
+	got, overflow := tierCandidates(proxies, 3)
+	if overflow || len(got) != 1 || got[0].IP != "10.0.0.3" {
+		t.Errorf("got %v overflow=%v, want [10.0.0.3]", got, overflow)
+	}
+
+	// 高优先级有空闲时不使用低优先级
+	proxies[1].InUseCount = 2
+	got, _ = tierCandidates(proxies, 3)
+	if len(got) != 1 || got[0].IP != "10.0.0.2" {
+		t.Errorf("got %v, want [10.0.0.2]", got)
+	}
+
+	// 全部满载时从最高优先级中选择
+	proxies[4].InUseCount = 1
+	got, overflow = tierCandidates(proxies, 1)
+	if !overflow || len(got) != 2 || got[0].Priority != 2 || got[1].Priority != 2 {
+		t.Errorf("got %v overflow=%v, want priority 2 tier", got, overflow)
+	}
+}
+
+func TestSelectorsRespectWeight(t *testing.T) {
+	proxies := []models.Proxy{
+		{IP: "10.0.0.1", Weight: 1},
+		{IP: "10.0.0.2", Weight: 3},
+	}
+
+	// 最低负载层内按权重：r ∈ [0,1) 落到 .1，r ∈ [1,4) 落到 .2
+	for r, want := range map[int]string{0: "10.0.0.1", 1: "10.0.0.2", 3: "10.0.0.2"} {
+		r := r
+		s := &leastUsedSelector{intn: func(n int) int {
+			if n != 4 {
+				t.Fatalf("总权重 got %d, want 4", n)
+			}
+			return r
+		}}
+		if got := s.Select(proxies, &models.Emulator{}); got.IP != want {
+			t.Errorf("least_used r=%d: got %s, want %s", r, got.IP, want)
+		}
+	}
+
+	// 轮询时每个代理连续被选中的次数等于其权重
+	rr := &roundRobinSelector{counters: &roundRobinCounters{count: map[int64]uint64{}}}
+	want := []string{"10.0.0.1", "10.0.0.2", "10.0.0.2", "10.0.0.2", "10.0.0.1"}
+	for i, ip := range want {
+		if got := rr.Select(proxies, &models.Emulator{}); got.IP != ip {
+			t.Errorf("round_robin 第 %d 次: got %s, want %s", i+1, got.IP, ip)
+		}
+	}
+
+	// 一致性哈希下高权重代理分到更多模拟器
+	ch := &consistentHashSelector{}
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		counts[ch.Select(proxies, &models.Emulator{UUID: fmt.Sprintf("emulator-%d", i)}).IP]++
+	}
+	if counts["10.0.0.2"] <= 2*counts["10.0.0.1"] {
+		t.Errorf("consistent_hash 分布不符合权重: %v", counts)
+	}
+}
+
+func TestLowLatencySelector(t *testing.T) {
+	proxies := []models.Proxy{
+		{IP: "10.0.0.1", LatencyMs: 0}, // 未检测
+		{IP: "10.0.0.2", LatencyMs: 200},
+		{IP: "10.0.0.3", LatencyMs: 100},
+		{IP: "10.0.0.4", LatencyMs: 115},
+		{IP: "10.0.0.5", LatencyMs: 130},
+	}
+
+	// 最低延迟 100ms，容差内（≤120ms）只有 .3 和 .4
+	var size int
+	s := &lowLatencySelector{intn: func(n int) int { size = n; return n - 1 }}
+	got := s.Select(proxies, &models.Emulator{})
+	if size != 2 || got.IP != "10.0.0.4" {
+		t.Errorf("got %s (pool %d), want 10.0.0.4 (pool 2)", got.IP, size)
+	}
+
+	// 避开当前 IP 后最低延迟变为 115ms
+	got = s.Select(proxies, &models.Emulator{IP: "10.0.0.3"})
+	if size != 2 || got.IP != "10.0.0.5" {
+		t.Errorf("got %s (pool %d), want 10.0.0.5 (pool 2)", got.IP, size)
+	}
+
+	// 全部未检测时按权重随机
+	unknown := []models.Proxy{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}}
+	got = s.Select(unknown, &models.Emulator{})
+	if size != 2 || got.IP != "10.0.0.2" {
+		t.Errorf("got %s (pool %d), want 10.0.0.2 (pool 2)", got.IP, size)
+	}
+}
+
+func TestFilterAssignable(t *testing.T) {
+	now := int64(1000)
+	proxies := []models.Proxy{
+		{IP: "10.0.0.1"},
+		{IP: "10.0.0.2", CooldownUntil: now + 60, LastReleasedBy: "emu-a"},
+		{IP: "10.0.0.3", CooldownUntil: now, LastReleasedBy: "emu-a"}, // 冷却刚结束
+	}
+
+	got := filterAssignable(proxies, &models.Emulator{UUID: "emu-b"}, now)
+	if len(got) != 2 || got[0].IP != "10.0.0.1" || got[1].IP != "10.0.0.3" {
+		t.Errorf("got %v, want 10.0.0.1 and 10.0.0.3", got)
+	}
+
+	// 冷却期内仍可分配给释放它的模拟器
+	got = filterAssignable(proxies, &models.Emulator{UUID: "emu-a"}, now)
+	if len(got) != 3 {
+		t.Errorf("got %d proxies, want 3", len(got))
+	}
+}
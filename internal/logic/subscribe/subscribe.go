@@ -2,7 +2,9 @@ package subscribe
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/maxliu9403/ProxyHub/internal/logic/token"
 
@@ -37,6 +39,16 @@ func (s *Svc) getEmulatorRepo() repo.EmulatorRepo {
 	return factory.EmulatorRepo(s.DB)
 }
 
+func (s *Svc) getBindingHistoryRepo() repo.BindingHistoryRepo {
+	s.DB = gormdb.Cli(s.Ctx)
+	return factory.BindingHistoryRepo(s.DB)
+}
+
+func (s *Svc) getTemplateRepo() repo.TemplateRepo {
+	s.DB = gormdb.Cli(s.Ctx)
+	return factory.TemplateRepo(s.DB)
+}
+
 func (s *Svc) getProxies(groupId int64) (err error, proxies []models.Proxy) {
 	query := models.GetListParams{
 		GroupIDs: []int64{groupId},
@@ -44,12 +56,20 @@ func (s *Svc) getProxies(groupId int64) (err error, proxies []models.Proxy) {
 			Order: "inuse_count desc",
 		},
 	}
-	proxies = make([]models.Proxy, 0)
-	_, err = s.getProxyRepo().GetList(query, &models.Proxy{}, &proxies)
+	all := make([]models.Proxy, 0)
+	_, err = s.getProxyRepo().GetList(query, &models.Proxy{}, &all)
 	if err != nil {
 		logger.ErrorfWithTrace(s.Ctx, "get proxies: %s", err.Error())
 		return
 	}
+
+	// 排除健康检测判定为不可用、或类型暂不支持下发的代理
+	proxies = make([]models.Proxy, 0, len(all))
+	for _, p := range all {
+		if p.Usable() {
+			proxies = append(proxies, p)
+		}
+	}
 	return
 }
 
@@ -74,23 +94,106 @@ func (s *Svc) prepareAndSelectProxy(token, uuid string) (*models.Emulator, *mode
 	return emulator, group, nil
 }
 
-func (s *Svc) Subscribe(token string, uuid string) (clashCfg string, err error) {
+// SubscribeOptions 订阅请求选项
+type SubscribeOptions struct {
+	Format Format // 输出格式
+	Rotate bool   // 是否强制切换 IP，未设置时由分组轮换策略决定
+
+	IfNoneMatch string // 客户端携带的 If-None-Match，命中时返回 NotModified
+}
+
+// SubscribeResult 订阅结果
+type SubscribeResult struct {
+	Config      string            // 渲染后的配置，NotModified 时为空
+	ETag        string            // 配置内容的 ETag
+	NotModified bool              // If-None-Match 命中，客户端配置无需更新
+	Headers     map[string]string // 分组配置的订阅响应头
+}
+
+// currentProxy 返回模拟器当前绑定且仍属于本分组、满足地区要求、健康可用的代理，未绑定或代理已失效时返回 nil
+func (s *Svc) currentProxy(emulator *models.Emulator) (*models.Proxy, error) {
+	if emulator.IP == "" {
+		return nil, nil
+	}
+
+	proxy, err := s.getProxyRepo().GetByIP(emulator.IP)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.WarnfWithTrace(s.Ctx, "模拟器 %s 绑定的代理 %s 已不存在，将重新绑定", emulator.UUID, emulator.IP)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询当前绑定代理失败: %w", err)
+	}
+	if proxy.GroupID != emulator.GroupID {
+		logger.WarnfWithTrace(s.Ctx, "模拟器 %s 绑定的代理 %s 不属于当前分组，将重新绑定", emulator.UUID, emulator.IP)
+		return nil, nil
+	}
+	if !matchGeo(proxy, emulator) {
+		logger.WarnfWithTrace(s.Ctx, "模拟器 %s 绑定的代理 %s 不满足地区要求，将重新绑定", emulator.UUID, emulator.IP)
+		return nil, nil
+	}
+	if !proxy.Usable() {
+		logger.WarnfWithTrace(s.Ctx, "模拟器 %s 绑定的代理 %s 健康检测不通过或类型不支持下发，将重新绑定", emulator.UUID, emulator.IP)
+		return nil, nil
+	}
+	return proxy, nil
+}
+
+func (s *Svc) Subscribe(token string, uuid string, opts SubscribeOptions) (result *SubscribeResult, err error) {
 	// Step 1: 校验并准备数据
 	emulator, group, err := s.prepareAndSelectProxy(token, uuid)
 	if err != nil {
 		return
 	}
+	result = &SubscribeResult{Headers: groupHeaders(group)}
+
+	// Step 2: 条件请求且本次无需切换时，只读地按当前绑定渲染并比对 ETag，命中则直接返回 NotModified，
+	// 不触碰绑定、历史、冷却和备用代理记录；需要切换或重新绑定的请求总是返回完整配置
+	if conditional(opts, group, emulator, time.Now()) {
+		var notModified bool
+		if notModified, err = s.notModified(opts, token, emulator, group, result); err != nil || notModified {
+			return
+		}
+	}
 
-	// Step 2: 代理切换（幂等 + 原子 + 事务 + 锁）
-	selectedProxy, err := s.switchProxy(emulator, group)
+	// Step 3: 按分组轮换策略沿用或切换代理（幂等 + 原子 + 事务 + 锁）
+	selectedProxy, err := s.switchProxy(emulator, group, opts.Rotate)
 	if err != nil {
 		return
 	}
-	// Step 3: 渲染 Clash 配置
-	clashCfg, err = s.renderClashConfig(selectedProxy)
+
+	// Step 4: 按格式渲染配置，ETag 取自本次实际渲染的内容
+	result.Config, err = s.renderConfig(opts.Format, token, emulator, group, selectedProxy, true)
 	if err != nil {
-		logger.ErrorfWithTrace(s.Ctx, "渲染Clash配置失败: %s", err.Error())
+		logger.ErrorfWithTrace(s.Ctx, "渲染%s配置失败: %s", opts.Format, err.Error())
 		return
 	}
+	result.ETag = computeETag(result.Config)
 	return
 }
+
+// conditional 判断本次请求能否走只读的条件请求流程：携带 If-None-Match 且分组策略不要求切换
+func conditional(opts SubscribeOptions, group *models.Groups, emulator *models.Emulator, now time.Time) bool {
+	return opts.IfNoneMatch != "" && !shouldRotate(group, emulator, opts.Rotate, now)
+}
+
+// notModified 只读地按当前绑定渲染配置，ETag 与 If-None-Match 一致时设置 NotModified；
+// 当前绑定已失效（需重新绑定）时返回 false，由完整流程处理
+func (s *Svc) notModified(opts SubscribeOptions, token string, emulator *models.Emulator, group *models.Groups, result *SubscribeResult) (bool, error) {
+	current, err := s.currentProxy(emulator)
+	if err != nil || current == nil {
+		return false, err
+	}
+	cfg, err := s.renderConfig(opts.Format, token, emulator, group, current, false)
+	if err != nil {
+		logger.ErrorfWithTrace(s.Ctx, "渲染%s配置失败: %s", opts.Format, err.Error())
+		return false, err
+	}
+	etag := computeETag(cfg)
+	if !etagMatch(opts.IfNoneMatch, etag) {
+		return false, nil
+	}
+	result.ETag = etag
+	result.NotModified = true
+	return true, nil
+}
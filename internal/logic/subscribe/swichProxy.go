@@ -2,7 +2,9 @@ package subscribe
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/maxliu9403/ProxyHub/internal/common"
 	"github.com/maxliu9403/ProxyHub/internal/logic"
 	"github.com/maxliu9403/ProxyHub/models"
 	"github.com/maxliu9403/ProxyHub/models/factory"
@@ -10,19 +12,35 @@ import (
 	"gorm.io/gorm"
 )
 
-func (s *Svc) bindEmulatorToProxyIP(tx *gorm.DB, emulator *models.Emulator, selected *models.Proxy) error {
+func (s *Svc) bindEmulatorToProxyIP(tx *gorm.DB, emulator *models.Emulator, group *models.Groups, selected *models.Proxy) error {
 	proxyRepo := factory.ProxyRepo(tx)
 	emulatorRepo := factory.EmulatorRepo(tx)
+	historyRepo := factory.BindingHistoryRepo(tx)
+	now := time.Now().Unix()
 	if emulator.IP == selected.IP {
+		// 重新选择后仍落到原 IP 时也重新开始计时，否则 sticky 绑定会一直被判定为过期，每次订阅都重新选择
+		if err := emulatorRepo.Update(emulator.UUID, map[string]interface{}{"bound_at": now}); err != nil {
+			return fmt.Errorf("更新模拟器绑定时间失败: %w", err)
+		}
 		logger.InfofWithTrace(s.Ctx, "模拟器 %s 绑定IP未变更: %s", emulator.UUID, emulator.IP)
 		return nil
 	}
 
+	reason := models.BindReasonBind
+
 	// 解绑旧 IP
 	if emulator.IP != "" {
+		reason = models.BindReasonRotate
 		if err := proxyRepo.DecrementInUseTx(tx, emulator.IP, 1); err != nil {
 			return fmt.Errorf("旧IP %s 减少使用数失败: %w", emulator.IP, err)
 		}
+		// 旧 IP 仍被其他模拟器共用时不进入冷却，见 SetCooldownTx
+		if err := proxyRepo.SetCooldownTx(tx, emulator.IP, now, group.CooldownUntil(now), emulator.UUID); err != nil {
+			return fmt.Errorf("旧IP %s 设置冷却失败: %w", emulator.IP, err)
+		}
+		if err := historyRepo.CloseByUuids([]string{emulator.UUID}, reason, now); err != nil {
+			return fmt.Errorf("记录旧IP %s 解绑历史失败: %w", emulator.IP, err)
+		}
 	}
 
 	// 绑定新 IP
@@ -31,34 +49,97 @@ func (s *Svc) bindEmulatorToProxyIP(tx *gorm.DB, emulator *models.Emulator, sele
 	}
 
 	// 更新 Emulator 表
-	if err := emulatorRepo.Update(emulator.UUID, map[string]interface{}{"ip": selected.IP}); err != nil {
+	if err := emulatorRepo.Update(emulator.UUID, map[string]interface{}{"ip": selected.IP, "bound_at": now}); err != nil {
 		return fmt.Errorf("更新模拟器绑定IP失败: %w", err)
 	}
 
+	// 记录绑定历史
+	if err := historyRepo.Create(&models.BindingHistory{
+		EmulatorUUID: emulator.UUID,
+		GroupID:      emulator.GroupID,
+		IP:           selected.IP,
+		BindReason:   reason,
+		BindTime:     now,
+	}); err != nil {
+		return fmt.Errorf("记录新IP %s 绑定历史失败: %w", selected.IP, err)
+	}
+
 	logger.InfofWithTrace(s.Ctx, "模拟器 %s IP 已更新为: %s", emulator.UUID, selected.IP)
 	return nil
 }
 
-func (s *Svc) switchProxy(emulator *models.Emulator, group *models.Groups) (selected *models.Proxy, err error) {
+// switchProxy 按分组轮换策略决定沿用当前绑定还是重新选择代理，requested 表示调用方显式要求切换
+func (s *Svc) switchProxy(emulator *models.Emulator, group *models.Groups, requested bool) (selected *models.Proxy, err error) {
+	// 策略不要求切换时，沿用当前绑定的代理
+	if !shouldRotate(group, emulator, requested, time.Now()) {
+		selected, err = s.currentProxy(emulator)
+		if err != nil || selected != nil {
+			if selected != nil && emulator.BoundAt == 0 {
+				s.markBoundAt(emulator)
+			}
+			return selected, err
+		}
+	}
+
 	// 获取全部代理列表
 	err, proxies := s.getProxies(emulator.GroupID)
 	if err != nil {
 		return nil, err
 	}
 
-	// 初始候选列表：负载最小、未超限
-	// 筛选出当前使用数 (InUseCount) 小于最大在线限制 group.MaxOnline，且使用数最小的代理列表，作为候选集合。
-	// 如果候选集合为空（即所有代理都已满载），
-	// 直接从所有代理池中随机选一个，不考虑负载限制，作为备选。
-	// 否则，使用候选集合。
-	candidates := selectLeastUsedProxies(proxies, int64(group.MaxOnline))
-	if len(candidates) == 0 {
-		logger.WarnfWithTrace(s.Ctx, "代理池全部已满，UUID: %s，将从所有代理中随机选一个", emulator.UUID)
-		selected = pickRandomProxy(proxies, emulator.IP)
-	} else {
-		selected = pickRandomProxy(candidates, emulator.IP)
+	if len(proxies) == 0 {
+		return nil, fmt.Errorf("分组 %d 下没有可用代理", emulator.GroupID)
 	}
 
+	// 排除处于释放冷却期、且不是由本模拟器释放的代理
+	now := time.Now().Unix()
+	proxies = filterAssignable(proxies, emulator, now)
+	if len(proxies) == 0 {
+		return nil, fmt.Errorf("分组 %d 下的代理均处于释放冷却期", emulator.GroupID)
+	}
+
+	// 历史IP策略：avoid 排除近期用过的IP（之后的满载备选和重选同样只在排除后的代理中进行），
+	// affinity 在容量允许时优先回到近期用过的IP
+	history, err := s.historyIPs(emulator, group, now)
+	if err != nil {
+		return nil, fmt.Errorf("查询模拟器绑定历史失败: %w", err)
+	}
+	if group.HistoryPolicy == models.HistoryAvoid {
+		proxies = filterHistory(proxies, history)
+		if len(proxies) == 0 {
+			return nil, fmt.Errorf("分组 %d 下没有模拟器近 %d 天未使用过的代理", emulator.GroupID, group.HistoryDays)
+		}
+	}
+
+	// 模拟器配置了出口地区要求时，只在满足要求的代理中选择
+	constrained := emulator.GeoConstrained()
+	if constrained {
+		proxies = filterGeo(proxies, emulator)
+		if len(proxies) == 0 {
+			return nil, common.NewErrorCode(common.ErrNoGeoProxy,
+				fmt.Errorf("分组 %d 下没有位于 %s/%s 的可用代理", emulator.GroupID, emulator.Country, emulator.Region))
+		}
+	}
+
+	// 候选列表：未超过最大在线数的代理中优先级最高的一层，由分组的选择策略从中选出一个；
+	// 如果所有代理都已满载，则从所有代理中优先级最高的一层选择，不考虑负载限制，作为备选。
+	// 有地区要求的模拟器不使用满载备选，直接失败，避免分配到不合要求或超载的代理。
+	// affinity 在分层之前生效：所有优先级中未满载的历史IP都会被优先选回，满载备选时不偏向历史IP。
+	maxOnline := int64(group.MaxOnline)
+	var selector Selector = &tieredSelector{maxOnline: maxOnline, next: NewSelector(group)}
+	if group.HistoryPolicy == models.HistoryAffinity && len(history) > 0 {
+		selector = &affinitySelector{history: history, maxOnline: maxOnline, next: selector}
+	}
+	_, overflow := tierCandidates(proxies, maxOnline)
+	if overflow {
+		if constrained {
+			return nil, common.NewErrorCode(common.ErrNoGeoProxy,
+				fmt.Errorf("分组 %d 下位于 %s/%s 的代理均已满载", emulator.GroupID, emulator.Country, emulator.Region))
+		}
+		logger.WarnfWithTrace(s.Ctx, "代理池全部已满，UUID: %s，将从最高优先级代理中选择", emulator.UUID)
+	}
+	selected = selector.Select(proxies, emulator)
+
 	logger.InfofWithTrace(s.Ctx, "模拟器 %s 原IP: %s，初始选中IP: %s", emulator.UUID, emulator.IP, selected.IP)
 
 	// 开始事务（带最多3次尝试更换代理IP）
@@ -77,22 +158,26 @@ func (s *Svc) switchProxy(emulator *models.Emulator, group *models.Groups) (sele
 				return fmt.Errorf("获取代理最新信息失败: %w", err)
 			}
 
-			if selectedLatest.InUseCount+1 <= int64(group.MaxOnline) {
+			if selectedLatest.InUseCount+1 <= maxOnline {
 				// 合法，执行切换逻辑
-				return s.bindEmulatorToProxyIP(tx, emulator, selected)
+				return s.bindEmulatorToProxyIP(tx, emulator, group, selected)
 			}
 
 			// 当前 IP 已满，尝试重新选择一个未尝试过的 IP
 			logger.WarnfWithTrace(s.Ctx, "代理 %s 超载（%d），尝试重新选择", selected.IP, selectedLatest.InUseCount)
 			untried := filterUntriedProxies(proxies, tried)
 			if len(untried) == 0 {
+				if constrained {
+					return common.NewErrorCode(common.ErrNoGeoProxy,
+						fmt.Errorf("分组 %d 下位于 %s/%s 的代理均已满载", emulator.GroupID, emulator.Country, emulator.Region))
+				}
 				logger.WarnfWithTrace(s.Ctx, "无其他可选代理，强制继续使用 %s", selected.IP)
 				break // 最后一次容忍
 			}
-			selected = pickRandomProxy(untried, emulator.IP)
+			selected = selector.Select(untried, emulator)
 			logger.InfofWithTrace(s.Ctx, "重新选择代理，尝试新IP: %s", selected.IP)
 		}
-		return s.bindEmulatorToProxyIP(tx, emulator, selected)
+		return s.bindEmulatorToProxyIP(tx, emulator, group, selected)
 	}, 3)
 
 	if err != nil {
@@ -101,3 +186,10 @@ func (s *Svc) switchProxy(emulator *models.Emulator, group *models.Groups) (sele
 
 	return selected, nil
 }
+
+// markBoundAt 为缺少绑定开始时间的历史绑定补记当前时间，失败不影响订阅
+func (s *Svc) markBoundAt(emulator *models.Emulator) {
+	if err := s.getEmulatorRepo().Update(emulator.UUID, map[string]interface{}{"bound_at": time.Now().Unix()}); err != nil {
+		logger.WarnfWithTrace(s.Ctx, "补记模拟器 %s 绑定时间失败: %s", emulator.UUID, err.Error())
+	}
+}
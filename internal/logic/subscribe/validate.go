@@ -0,0 +1,522 @@
+package subscribe
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// builtinPolicies 各客户端内置的策略名，规则和策略组可直接引用
+var builtinPolicies = map[string]bool{
+	"DIRECT":         true,
+	"REJECT":         true,
+	"REJECT-DROP":    true,
+	"REJECT-TINYGIF": true,
+	"PASS":           true,
+	"COMPATIBLE":     true,
+	"GLOBAL":         true,
+}
+
+// Validate 校验渲染结果的结构，返回发现的问题列表，为空表示通过
+func Validate(format Format, rendered string) []string {
+	switch format {
+	case FormatClash:
+		return validateClash(rendered)
+	case FormatSingBox:
+		return validateSingBox(rendered)
+	case FormatXray:
+		return validateXray(rendered)
+	case FormatSurge, FormatShadowrocket, FormatLoon:
+		return validateSurgeLike(rendered)
+	case FormatQuantumultX:
+		return validateQuantumultX(rendered)
+	case FormatURIList:
+		return validateURIList(rendered)
+	default:
+		return []string{fmt.Sprintf("不支持的订阅格式: %s", format)}
+	}
+}
+
+type clashConfig struct {
+	Proxies []struct {
+		Name   string      `yaml:"name"`
+		Type   string      `yaml:"type"`
+		Server string      `yaml:"server"`
+		Port   interface{} `yaml:"port"`
+	} `yaml:"proxies"`
+	ProxyGroups []struct {
+		Name                string   `yaml:"name"`
+		Type                string   `yaml:"type"`
+		Proxies             []string `yaml:"proxies"`
+		Use                 []string `yaml:"use"`
+		IncludeAll          bool     `yaml:"include-all"`
+		IncludeAllProxies   bool     `yaml:"include-all-proxies"`
+		IncludeAllProviders bool     `yaml:"include-all-providers"`
+		Filter              string   `yaml:"filter"`
+	} `yaml:"proxy-groups"`
+	ProxyProviders map[string]interface{} `yaml:"proxy-providers"`
+	Rules          []string               `yaml:"rules"`
+	SubRules       map[string][]string    `yaml:"sub-rules"`
+}
+
+func validateClash(rendered string) []string {
+	var cfg clashConfig
+	if err := yaml.Unmarshal([]byte(rendered), &cfg); err != nil {
+		return []string{fmt.Sprintf("YAML 解析失败: %s", err.Error())}
+	}
+
+	var problems []string
+	names := make(map[string]bool)
+	if len(cfg.Proxies) == 0 && len(cfg.ProxyProviders) == 0 {
+		problems = append(problems, "proxies 为空")
+	}
+	for i, p := range cfg.Proxies {
+		if p.Name == "" {
+			problems = append(problems, fmt.Sprintf("proxies[%d] 缺少 name", i))
+			continue
+		}
+		if names[p.Name] {
+			problems = append(problems, fmt.Sprintf("proxies 名称重复: %s", p.Name))
+		}
+		names[p.Name] = true
+		if p.Type == "" {
+			problems = append(problems, fmt.Sprintf("代理 %s 缺少 type", p.Name))
+		}
+		if p.Server == "" {
+			problems = append(problems, fmt.Sprintf("代理 %s 缺少 server", p.Name))
+		}
+		if p.Port == nil {
+			problems = append(problems, fmt.Sprintf("代理 %s 缺少 port", p.Name))
+		}
+	}
+
+	for _, g := range cfg.ProxyGroups {
+		if g.Name == "" {
+			problems = append(problems, "proxy-groups 存在缺少 name 的分组")
+			continue
+		}
+		if names[g.Name] {
+			problems = append(problems, fmt.Sprintf("proxy-groups 名称与代理或其他分组重复: %s", g.Name))
+		}
+		names[g.Name] = true
+	}
+	for _, g := range cfg.ProxyGroups {
+		// mihomo 的 include-all 系列和 filter 会在运行时收集成员，无法静态判断是否为空
+		dynamic := g.IncludeAll || g.IncludeAllProxies || g.IncludeAllProviders || g.Filter != ""
+		if len(g.Proxies) == 0 && len(g.Use) == 0 && !dynamic {
+			problems = append(problems, fmt.Sprintf("proxy-group %s 没有任何代理", g.Name))
+		}
+		for _, p := range g.Proxies {
+			if !names[p] && !builtinPolicies[p] {
+				problems = append(problems, fmt.Sprintf("proxy-group %s 引用了不存在的代理: %s", g.Name, p))
+			}
+		}
+		for _, u := range g.Use {
+			if _, ok := cfg.ProxyProviders[u]; !ok {
+				problems = append(problems, fmt.Sprintf("proxy-group %s 引用了不存在的 proxy-provider: %s", g.Name, u))
+			}
+		}
+	}
+
+	if len(cfg.Rules) == 0 {
+		problems = append(problems, "rules 为空")
+	}
+	problems = append(problems, checkClashRules(cfg.Rules, names, cfg.SubRules)...)
+	for name, rules := range cfg.SubRules {
+		for _, p := range checkClashRules(rules, names, cfg.SubRules) {
+			problems = append(problems, fmt.Sprintf("sub-rules %s: %s", name, p))
+		}
+	}
+	return problems
+}
+
+// checkClashRules 校验规则格式及目标策略；SUB-RULE 的目标是 sub-rules 中的名称
+func checkClashRules(rules []string, names map[string]bool, subRules map[string][]string) []string {
+	var problems []string
+	for _, r := range rules {
+		ruleType, target := clashRuleTarget(r)
+		if target == "" {
+			problems = append(problems, fmt.Sprintf("规则格式错误: %s", r))
+			continue
+		}
+		if strings.EqualFold(ruleType, "SUB-RULE") {
+			if _, ok := subRules[target]; !ok {
+				problems = append(problems, fmt.Sprintf("规则 %s 引用了不存在的 sub-rule: %s", r, target))
+			}
+			continue
+		}
+		if !names[target] && !builtinPolicies[target] {
+			problems = append(problems, fmt.Sprintf("规则 %s 引用了不存在的策略: %s", r, target))
+		}
+	}
+	return problems
+}
+
+// clashRuleTarget 返回规则类型和目标策略：MATCH 规则为第二段，其余为第三段。
+// AND/OR/NOT、SUB-RULE 的条件写在括号里且包含逗号，按括号层级切分
+func clashRuleTarget(rule string) (ruleType, target string) {
+	parts := splitClashRule(rule)
+	ruleType = parts[0]
+	if strings.EqualFold(ruleType, "MATCH") || strings.EqualFold(ruleType, "FINAL") {
+		if len(parts) < 2 {
+			return ruleType, ""
+		}
+		return ruleType, parts[1]
+	}
+	if len(parts) < 3 {
+		return ruleType, ""
+	}
+	return ruleType, parts[2]
+}
+
+// splitClashRule 按顶层逗号切分规则，括号内的逗号不切分
+func splitClashRule(rule string) []string {
+	var (
+		parts []string
+		depth int
+		start int
+	)
+	for i, r := range rule {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(rule[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(rule[start:]))
+}
+
+type singBoxConfig struct {
+	Outbounds []struct {
+		Type      string   `json:"type"`
+		Tag       string   `json:"tag"`
+		Outbounds []string `json:"outbounds"`
+	} `json:"outbounds"`
+	Route *struct {
+		Final string `json:"final"`
+		Rules []struct {
+			Outbound string `json:"outbound"`
+		} `json:"rules"`
+	} `json:"route"`
+}
+
+func validateSingBox(rendered string) []string {
+	var cfg singBoxConfig
+	if err := json.Unmarshal([]byte(rendered), &cfg); err != nil {
+		return []string{fmt.Sprintf("JSON 解析失败: %s", err.Error())}
+	}
+
+	var problems []string
+	if len(cfg.Outbounds) == 0 {
+		problems = append(problems, "outbounds 为空")
+	}
+	tags := make(map[string]bool)
+	for i, o := range cfg.Outbounds {
+		if o.Tag == "" {
+			problems = append(problems, fmt.Sprintf("outbounds[%d] 缺少 tag", i))
+			continue
+		}
+		if tags[o.Tag] {
+			problems = append(problems, fmt.Sprintf("outbounds tag 重复: %s", o.Tag))
+		}
+		tags[o.Tag] = true
+	}
+	for _, o := range cfg.Outbounds {
+		for _, ref := range o.Outbounds {
+			if !tags[ref] {
+				problems = append(problems, fmt.Sprintf("outbound %s 引用了不存在的 outbound: %s", o.Tag, ref))
+			}
+		}
+	}
+	if cfg.Route != nil {
+		if cfg.Route.Final != "" && !tags[cfg.Route.Final] {
+			problems = append(problems, fmt.Sprintf("route.final 引用了不存在的 outbound: %s", cfg.Route.Final))
+		}
+		for i, r := range cfg.Route.Rules {
+			if r.Outbound != "" && !tags[r.Outbound] {
+				problems = append(problems, fmt.Sprintf("route.rules[%d] 引用了不存在的 outbound: %s", i, r.Outbound))
+			}
+		}
+	}
+	return problems
+}
+
+type xrayConfig struct {
+	Outbounds []struct {
+		Tag      string `json:"tag"`
+		Protocol string `json:"protocol"`
+	} `json:"outbounds"`
+	Routing *struct {
+		Rules []struct {
+			OutboundTag string `json:"outboundTag"`
+			BalancerTag string `json:"balancerTag"`
+		} `json:"rules"`
+		Balancers []struct {
+			Tag      string   `json:"tag"`
+			Selector []string `json:"selector"`
+		} `json:"balancers"`
+	} `json:"routing"`
+}
+
+func validateXray(rendered string) []string {
+	var cfg xrayConfig
+	if err := json.Unmarshal([]byte(rendered), &cfg); err != nil {
+		return []string{fmt.Sprintf("JSON 解析失败: %s", err.Error())}
+	}
+
+	var problems []string
+	if len(cfg.Outbounds) == 0 {
+		problems = append(problems, "outbounds 为空")
+	}
+	tags := make(map[string]bool)
+	for i, o := range cfg.Outbounds {
+		if o.Protocol == "" {
+			problems = append(problems, fmt.Sprintf("outbounds[%d] 缺少 protocol", i))
+		}
+		if o.Tag == "" {
+			continue
+		}
+		if tags[o.Tag] {
+			problems = append(problems, fmt.Sprintf("outbounds tag 重复: %s", o.Tag))
+		}
+		tags[o.Tag] = true
+	}
+	if cfg.Routing != nil {
+		balancers := make(map[string]bool)
+		for _, b := range cfg.Routing.Balancers {
+			balancers[b.Tag] = true
+		}
+		for i, r := range cfg.Routing.Rules {
+			if r.OutboundTag != "" && !tags[r.OutboundTag] {
+				problems = append(problems, fmt.Sprintf("routing.rules[%d] 引用了不存在的 outboundTag: %s", i, r.OutboundTag))
+			}
+			if r.BalancerTag != "" && !balancers[r.BalancerTag] {
+				problems = append(problems, fmt.Sprintf("routing.rules[%d] 引用了不存在的 balancerTag: %s", i, r.BalancerTag))
+			}
+		}
+	}
+	return problems
+}
+
+// parseSections 按 [Section] 切分 ini 风格配置，section 名统一小写，忽略注释和空行
+func parseSections(rendered string) map[string][]string {
+	sections := make(map[string][]string)
+	current := ""
+	scanner := bufio.NewScanner(strings.NewReader(rendered))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			if _, ok := sections[current]; !ok {
+				sections[current] = []string{}
+			}
+			continue
+		}
+		sections[current] = append(sections[current], line)
+	}
+	return sections
+}
+
+// splitKV 拆分 name = value 形式的行
+func splitKV(line string) (string, string, bool) {
+	idx := strings.Index(line, "=")
+	if idx < 0 {
+		return "", "", false
+	}
+	return strings.TrimSpace(line[:idx]), strings.TrimSpace(line[idx+1:]), true
+}
+
+// splitFields 按逗号切分字段，dquote 输出的双引号字段内的逗号和转义字符不切分
+func splitFields(value string) []string {
+	var (
+		parts   []string
+		start   int
+		quoted  bool
+		escaped bool
+	)
+	for i, r := range value {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case r == ',' && !quoted:
+			parts = append(parts, strings.TrimSpace(value[start:i]))
+			start = i + 1
+		}
+	}
+	return append(parts, strings.TrimSpace(value[start:]))
+}
+
+// validateSurgeLike 校验 Surge / Shadowrocket / Loon 配置
+func validateSurgeLike(rendered string) []string {
+	sections := parseSections(rendered)
+	var problems []string
+
+	names := make(map[string]bool)
+	proxies, ok := sections["proxy"]
+	if !ok || len(proxies) == 0 {
+		problems = append(problems, "[Proxy] 为空")
+	}
+	for _, line := range proxies {
+		name, value, ok := splitKV(line)
+		if !ok || name == "" || value == "" {
+			problems = append(problems, fmt.Sprintf("[Proxy] 行格式错误: %s", line))
+			continue
+		}
+		if len(splitFields(value)) < 3 {
+			problems = append(problems, fmt.Sprintf("代理 %s 缺少服务器或端口", name))
+		}
+		names[name] = true
+	}
+
+	groups := sections["proxy group"]
+	for _, line := range groups {
+		name, _, ok := splitKV(line)
+		if !ok || name == "" {
+			problems = append(problems, fmt.Sprintf("[Proxy Group] 行格式错误: %s", line))
+			continue
+		}
+		names[name] = true
+	}
+	for _, line := range groups {
+		name, value, ok := splitKV(line)
+		if !ok {
+			continue
+		}
+		fields := splitFields(value)
+		if len(fields) < 2 {
+			problems = append(problems, fmt.Sprintf("策略组 %s 没有任何代理", name))
+			continue
+		}
+		for _, member := range fields[1:] {
+			// 跳过 url=xxx、interval=xxx 等选项
+			if strings.Contains(member, "=") || member == "" {
+				continue
+			}
+			if !names[member] && !builtinPolicies[strings.ToUpper(member)] {
+				problems = append(problems, fmt.Sprintf("策略组 %s 引用了不存在的代理: %s", name, member))
+			}
+		}
+	}
+
+	rules := sections["rule"]
+	if len(rules) == 0 {
+		problems = append(problems, "[Rule] 为空")
+	}
+	for _, r := range rules {
+		target := surgeRuleTarget(r)
+		if target == "" {
+			problems = append(problems, fmt.Sprintf("规则格式错误: %s", r))
+			continue
+		}
+		if !names[target] && !builtinPolicies[strings.ToUpper(target)] {
+			problems = append(problems, fmt.Sprintf("规则 %s 引用了不存在的策略: %s", r, target))
+		}
+	}
+	return problems
+}
+
+// surgeRuleTarget 提取规则策略，忽略 no-resolve 等尾部选项
+func surgeRuleTarget(rule string) string {
+	fields := splitFields(rule)
+	if strings.EqualFold(fields[0], "FINAL") {
+		if len(fields) < 2 {
+			return ""
+		}
+		return fields[1]
+	}
+	if len(fields) < 3 {
+		return ""
+	}
+	return fields[2]
+}
+
+func validateQuantumultX(rendered string) []string {
+	sections := parseSections(rendered)
+	var problems []string
+
+	names := make(map[string]bool)
+	servers := sections["server_local"]
+	if len(servers) == 0 && len(sections["server_remote"]) == 0 {
+		problems = append(problems, "[server_local] 为空")
+	}
+	for _, line := range servers {
+		tag := ""
+		for _, f := range splitFields(line) {
+			if k, v, ok := splitKV(f); ok && k == "tag" {
+				tag = v
+			}
+		}
+		if tag == "" {
+			problems = append(problems, fmt.Sprintf("[server_local] 缺少 tag: %s", line))
+			continue
+		}
+		names[tag] = true
+	}
+
+	policies := sections["policy"]
+	for _, line := range policies {
+		_, value, ok := splitKV(line)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("[policy] 行格式错误: %s", line))
+			continue
+		}
+		names[splitFields(value)[0]] = true
+	}
+	for _, line := range policies {
+		_, value, ok := splitKV(line)
+		if !ok {
+			continue
+		}
+		fields := splitFields(value)
+		for _, member := range fields[1:] {
+			if strings.Contains(member, "=") || member == "" {
+				continue
+			}
+			if !names[member] && !builtinPolicies[strings.ToUpper(member)] {
+				problems = append(problems, fmt.Sprintf("策略 %s 引用了不存在的节点: %s", fields[0], member))
+			}
+		}
+	}
+
+	for _, r := range sections["filter_local"] {
+		target := surgeRuleTarget(r)
+		if target == "" {
+			problems = append(problems, fmt.Sprintf("规则格式错误: %s", r))
+			continue
+		}
+		if !names[target] && !builtinPolicies[strings.ToUpper(target)] {
+			problems = append(problems, fmt.Sprintf("规则 %s 引用了不存在的策略: %s", r, target))
+		}
+	}
+	return problems
+}
+
+func validateURIList(rendered string) []string {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(rendered))
+	if err != nil {
+		return []string{fmt.Sprintf("base64 解码失败: %s", err.Error())}
+	}
+	if strings.TrimSpace(string(decoded)) == "" {
+		return []string{"URI 列表为空"}
+	}
+	return nil
+}
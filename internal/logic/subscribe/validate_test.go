@@ -0,0 +1,96 @@
+package subscribe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 默认模板使用示例数据渲染后必须能通过校验
+func TestValidateDefaultTemplates(t *testing.T) {
+	for format, r := range renderers {
+		content := ""
+		if tr, ok := r.(*templateRenderer); ok {
+			b, err := os.ReadFile(filepath.Join("..", "..", "..", "configs", tr.file))
+			if err != nil {
+				t.Fatalf("%s: %s", format, err.Error())
+			}
+			content = string(b)
+		}
+
+		rendered, err := Render(format, content, SampleTemplateData())
+		if err != nil {
+			t.Fatalf("%s: %s", format, err.Error())
+		}
+		if problems := Validate(format, rendered); len(problems) > 0 {
+			t.Fatalf("%s: %v", format, problems)
+		}
+	}
+}
+
+func TestValidateClashMissingProxy(t *testing.T) {
+	rendered := `
+proxies:
+  - name: residential
+    type: socks5
+    server: 1.1.1.1
+    port: 1080
+proxy-groups:
+  - name: AUTO
+    type: select
+    proxies:
+      - residential
+      - backup
+rules:
+  - DOMAIN-SUFFIX,example.com,DIRECT
+  - MATCH,PROXY
+`
+	problems := Validate(FormatClash, rendered)
+	if len(problems) != 2 {
+		t.Fatalf("expect 2 problems, got %v", problems)
+	}
+}
+
+func TestValidateSingBoxUnknownOutbound(t *testing.T) {
+	rendered := `{"outbounds":[{"type":"socks","tag":"residential"}],"route":{"final":"AUTO"}}`
+	if problems := Validate(FormatSingBox, rendered); len(problems) != 1 {
+		t.Fatalf("expect 1 problem, got %v", problems)
+	}
+}
+
+// mihomo 的逻辑规则、SUB-RULE 以及 include-all/filter 分组是合法写法
+func TestValidateClashMihomo(t *testing.T) {
+	rendered := `
+proxies:
+  - {name: residential, type: socks5, server: 1.1.1.1, port: 1080}
+proxy-groups:
+  - {name: ALL, type: select, include-all: true}
+  - {name: HK, type: url-test, include-all-proxies: true, filter: "(?i)港|hk"}
+  - {name: Proxy, type: select, proxies: [ALL, HK, residential]}
+sub-rules:
+  udp:
+    - NETWORK,UDP,REJECT
+    - MATCH,Proxy
+rules:
+  - AND,((DOMAIN,example.com),(NETWORK,UDP)),Proxy
+  - OR,((DOMAIN-SUFFIX,a.com),(DOMAIN-SUFFIX,b.com)),HK,no-resolve
+  - NOT,((DST-PORT,443)),DIRECT
+  - SUB-RULE,(NETWORK,UDP),udp
+  - MATCH,Proxy
+`
+	if problems := Validate(FormatClash, rendered); len(problems) > 0 {
+		t.Fatalf("expect no problems, got %v", problems)
+	}
+
+	rendered = `
+proxies:
+  - {name: residential, type: socks5, server: 1.1.1.1, port: 1080}
+rules:
+  - AND,((DOMAIN,example.com),(NETWORK,UDP)),Missing
+  - SUB-RULE,(NETWORK,UDP),missing
+  - MATCH,residential
+`
+	if problems := Validate(FormatClash, rendered); len(problems) != 2 {
+		t.Fatalf("expect 2 problems, got %v", problems)
+	}
+}
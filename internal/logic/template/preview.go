@@ -0,0 +1,184 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/maxliu9403/ProxyHub/internal/common"
+	"github.com/maxliu9403/ProxyHub/internal/logic/subscribe"
+	"github.com/maxliu9403/ProxyHub/models"
+	"github.com/maxliu9403/ProxyHub/models/factory"
+	"github.com/maxliu9403/ProxyHub/models/repo"
+	"github.com/maxliu9403/common/gormdb"
+	"github.com/maxliu9403/common/logger"
+)
+
+const (
+	dataSourceSample   = "sample"
+	dataSourceGroup    = "group"
+	dataSourceEmulator = "emulator"
+)
+
+func (s *Svc) getProxyRepo() repo.ProxyRepo {
+	s.DB = gormdb.Cli(s.Ctx)
+	return factory.ProxyRepo(s.DB)
+}
+
+func (s *Svc) getGroupRepo() repo.GroupsRepo {
+	s.DB = gormdb.Cli(s.Ctx)
+	return factory.GroupsRepo(s.DB)
+}
+
+func (s *Svc) getTokenRepo() repo.TokenRepo {
+	s.DB = gormdb.Cli(s.Ctx)
+	return factory.TokenRepo(s.DB)
+}
+
+type PreviewParams struct {
+	Format       string `json:"Format" binding:"required"` // 订阅格式
+	Content      string `json:"Content"`                   // 模板内容，为空时使用分组/模拟器当前生效的模板
+	GroupID      int64  `json:"GroupID"`                   // 使用该分组中的真实代理渲染
+	EmulatorUUID string `json:"EmulatorUUID"`              // 使用该模拟器当前绑定的代理渲染
+}
+
+type PreviewResp struct {
+	Format     string   `json:"Format"`     // 订阅格式
+	DataSource string   `json:"DataSource"` // 渲染数据来源：sample/group/emulator
+	Rendered   string   `json:"Rendered"`   // 渲染结果
+	Valid      bool     `json:"Valid"`      // 是否通过校验
+	Problems   []string `json:"Problems"`   // 校验发现的问题
+}
+
+// Preview 渲染并校验模板，不会修改任何绑定关系
+func (s *Svc) Preview(params PreviewParams) (*PreviewResp, error) {
+	format, err := subscribe.ParseFormat(params.Format)
+	if err != nil {
+		return nil, common.NewErrorCode(common.ErrInvalidParams, err)
+	}
+
+	data, source, groupID, err := s.previewData(params)
+	if err != nil {
+		return nil, common.NewErrorCode(common.ErrPreviewTemplate, err)
+	}
+
+	content := params.Content
+	if content == "" && groupID > 0 && subscribe.SupportsTemplate(format) {
+		t, err := s.getRepo().Resolve(groupID, params.EmulatorUUID, string(format))
+		if err != nil {
+			return nil, common.NewErrorCode(common.ErrPreviewTemplate, fmt.Errorf("查询模板绑定失败: %w", err))
+		}
+		if t != nil {
+			content = t.Content
+		}
+	}
+
+	resp := &PreviewResp{
+		Format:     string(format),
+		DataSource: source,
+		Problems:   []string{},
+	}
+	rendered, err := subscribe.Render(format, content, data)
+	if err != nil {
+		resp.Problems = append(resp.Problems, err.Error())
+		return resp, nil
+	}
+
+	resp.Rendered = rendered
+	resp.Problems = append(resp.Problems, subscribe.Validate(format, rendered)...)
+	resp.Valid = len(resp.Problems) == 0
+	return resp, nil
+}
+
+// previewData 按 模拟器 > 分组 > 示例 的顺序确定渲染数据，返回数据、来源以及所属分组。
+// 使用真实代理时与订阅走同一套数据构造，备用代理按当前负载选出，只读不记录
+func (s *Svc) previewData(params PreviewParams) (*subscribe.ClashTemplateData, string, int64, error) {
+	proxy, source, emulator, groupID, err := s.previewProxy(params)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if groupID == 0 {
+		return subscribe.SampleTemplateData(), source, 0, nil
+	}
+
+	// 真实分组时补充分组、模拟器和 Token 信息
+	group := &models.Groups{}
+	if err := s.getGroupRepo().GetByID(group, groupID); err != nil {
+		return nil, "", 0, fmt.Errorf("分组获取失败: %w", err)
+	}
+	token := ""
+	if t, err := s.getTokenRepo().GetByGroupID(groupID); err == nil {
+		token = t.Token
+	}
+
+	if proxy == nil {
+		data := subscribe.SampleTemplateData().SetGroup(group)
+		if emulator != nil {
+			data.SetEmulator(emulator)
+		}
+		data.Token = token
+		return data, source, groupID, nil
+	}
+
+	// 仅指定分组时没有模拟器，按空模拟器选择备用代理（不做地域约束和冷却豁免）
+	if emulator == nil {
+		emulator = &models.Emulator{GroupID: groupID}
+	}
+	sub := &subscribe.Svc{Ctx: s.Ctx}
+	data, err := sub.PreviewData(token, emulator, group, proxy)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return data, source, groupID, nil
+}
+
+// previewProxy 返回渲染使用的代理，为 nil 时使用示例数据
+func (s *Svc) previewProxy(params PreviewParams) (*models.Proxy, string, *models.Emulator, int64, error) {
+	groupID := params.GroupID
+	var emulator *models.Emulator
+
+	if params.EmulatorUUID != "" {
+		emulator = &models.Emulator{}
+		if err := s.getEmulatorRepo().GetByUuid(emulator, params.EmulatorUUID); err != nil {
+			return nil, "", nil, 0, fmt.Errorf("模拟器获取失败: %w", err)
+		}
+		if groupID > 0 && emulator.GroupID != groupID {
+			return nil, "", nil, 0, errors.New("模拟器不属于该分组")
+		}
+		groupID = emulator.GroupID
+
+		if emulator.IP != "" {
+			proxy, err := s.getProxyRepo().GetByIP(emulator.IP)
+			if err == nil {
+				return proxy, dataSourceEmulator, emulator, groupID, nil
+			}
+			logger.WarnfWithTrace(s.Ctx, "模拟器 %s 绑定的代理 %s 查询失败: %s", emulator.UUID, emulator.IP, err.Error())
+		}
+	}
+
+	if groupID > 0 {
+		proxies := make([]models.Proxy, 0)
+		query := models.GetListParams{GroupIDs: []int64{groupID}}
+		query.Limit = 1
+		if _, err := s.getProxyRepo().GetList(query, &models.Proxy{}, &proxies); err != nil {
+			return nil, "", nil, 0, fmt.Errorf("查询分组代理失败: %w", err)
+		}
+		if len(proxies) > 0 {
+			return &proxies[0], dataSourceGroup, emulator, groupID, nil
+		}
+	}
+
+	return nil, dataSourceSample, emulator, groupID, nil
+}
+
+// validateContent 使用示例数据渲染模板并校验，保存模板前调用
+func validateContent(format subscribe.Format, content string) error {
+	rendered, err := subscribe.Render(format, content, subscribe.SampleTemplateData())
+	if err != nil {
+		return err
+	}
+	if problems := subscribe.Validate(format, rendered); len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/maxliu9403/ProxyHub/internal/common"
+	"github.com/maxliu9403/ProxyHub/internal/logic/group"
+	"github.com/maxliu9403/ProxyHub/internal/logic/subscribe"
+	"github.com/maxliu9403/ProxyHub/models"
+	"github.com/maxliu9403/ProxyHub/models/factory"
+	"github.com/maxliu9403/ProxyHub/models/repo"
+	"github.com/maxliu9403/common/gormdb"
+	"github.com/maxliu9403/common/logger"
+	"gorm.io/gorm"
+)
+
+type Svc struct {
+	ID  int64
+	Ctx context.Context
+	DB  *gorm.DB
+}
+
+func (s *Svc) getRepo() repo.TemplateRepo {
+	s.DB = gormdb.Cli(s.Ctx)
+	return factory.TemplateRepo(s.DB)
+}
+
+func (s *Svc) getEmulatorRepo() repo.EmulatorRepo {
+	s.DB = gormdb.Cli(s.Ctx)
+	return factory.EmulatorRepo(s.DB)
+}
+
+// checkFormat 校验格式合法且支持自定义模板
+func checkFormat(format string) (subscribe.Format, error) {
+	f, err := subscribe.ParseFormat(format)
+	if err != nil {
+		return "", err
+	}
+	if !subscribe.SupportsTemplate(f) {
+		return "", fmt.Errorf("订阅格式 %s 不支持自定义模板", f)
+	}
+	return f, nil
+}
+
+func (s *Svc) GetList(q models.GetTemplateListParams) (data *common.ListData, err error) {
+	data = &common.ListData{}
+
+	crud := s.getRepo()
+	table := &models.Template{}
+	templates := make([]models.Template, 0)
+	total, err := crud.GetList(q, table, &templates)
+	if err != nil {
+		logger.ErrorfWithTrace(s.Ctx, "query list failed: %s", err.Error())
+		return data, common.NewErrorCode(common.ErrGetList, err)
+	}
+
+	data.Counts = total
+	data.Data = templates
+
+	return data, err
+}
+
+type TemplateDetailResp struct {
+	models.Template                           // 模板字段
+	Bindings        []*models.TemplateBinding `json:"Bindings"` // 绑定关系
+}
+
+func (s *Svc) Detail() (*TemplateDetailResp, error) {
+	t := &models.Template{}
+	if err := s.getRepo().GetByID(t, s.ID); err != nil {
+		return nil, common.NewErrorCode(common.ErrGetDetail, fmt.Errorf("查询模板 [%d] 详情失败: %w", s.ID, err))
+	}
+
+	bindings, err := s.getRepo().ListBindings(s.ID)
+	if err != nil {
+		logger.ErrorfWithTrace(s.Ctx, "list template bindings failed: %s", err.Error())
+		return nil, common.NewErrorCode(common.ErrGetDetail, err)
+	}
+
+	return &TemplateDetailResp{Template: *t, Bindings: bindings}, nil
+}
+
+func (s *Svc) Versions() ([]*models.TemplateVersion, error) {
+	list, err := s.getRepo().ListVersions(s.ID)
+	if err != nil {
+		logger.ErrorfWithTrace(s.Ctx, "list template versions failed: %s", err.Error())
+		return nil, common.NewErrorCode(common.ErrGetList, err)
+	}
+	return list, nil
+}
+
+type CreateParams struct {
+	Name        string `json:"Name" binding:"required"`    // 模板名，必须唯一
+	Format      string `json:"Format" binding:"required"`  // 订阅格式，例：clash/singbox/surge
+	Content     string `json:"Content" binding:"required"` // 模板内容
+	Description string `json:"Description"`                // 描述
+}
+
+func (s *Svc) Create(params CreateParams) (*models.Template, error) {
+	format, err := checkFormat(params.Format)
+	if err != nil {
+		return nil, common.NewErrorCode(common.ErrInvalidParams, err)
+	}
+
+	if err := validateContent(format, params.Content); err != nil {
+		return nil, common.NewErrorCode(common.ErrValidateTemplate, err)
+	}
+
+	model := &models.Template{
+		Name:        params.Name,
+		Format:      string(format),
+		Content:     params.Content,
+		Version:     1,
+		Description: params.Description,
+	}
+
+	err = gormdb.Cli(s.Ctx).Transaction(func(tx *gorm.DB) error {
+		templateRepo := factory.TemplateRepo(tx)
+		if err := templateRepo.Create(model); err != nil {
+			return err
+		}
+		return templateRepo.CreateVersion(&models.TemplateVersion{
+			TemplateID: model.ID,
+			Version:    model.Version,
+			Content:    model.Content,
+			Comment:    "创建",
+		})
+	})
+	if err != nil {
+		logger.ErrorfWithTrace(s.Ctx, "create template failed: %s", err.Error())
+		return nil, common.NewErrorCode(common.ErrCreateTemplate, err)
+	}
+
+	return model, nil
+}
+
+type UpdateParams struct {
+	ID          int64   `json:"ID" binding:"required"` // 模板 ID
+	Name        *string `json:"Name,omitempty"`        // 模板名
+	Content     *string `json:"Content,omitempty"`     // 模板内容，变更时生成新版本
+	Description *string `json:"Description,omitempty"` // 描述
+	Comment     string  `json:"Comment"`               // 版本变更说明
+}
+
+func (s *Svc) Update(params UpdateParams) error {
+	t := &models.Template{}
+	if err := s.getRepo().GetByID(t, params.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return common.NewErrorCode(common.ErrUpdateTemplate, errors.New("模板不存在"))
+		}
+		return common.NewErrorCode(common.ErrUpdateTemplate, err)
+	}
+	// 模板格式不可修改，内容校验可以在事务外进行
+	if params.Content != nil && *params.Content != t.Content {
+		if err := validateContent(subscribe.Format(t.Format), *params.Content); err != nil {
+			return common.NewErrorCode(common.ErrValidateTemplate, err)
+		}
+	}
+
+	err := gormdb.Cli(s.Ctx).Transaction(func(tx *gorm.DB) error {
+		templateRepo := factory.TemplateRepo(tx)
+		// 加锁读取当前版本，并发更新时版本号依次递增
+		current, err := templateRepo.GetByIDForUpdate(params.ID)
+		if err != nil {
+			return err
+		}
+
+		updateFields := map[string]interface{}{}
+		if params.Name != nil {
+			updateFields["name"] = *params.Name
+		}
+		if params.Description != nil {
+			updateFields["description"] = *params.Description
+		}
+		contentChanged := params.Content != nil && *params.Content != current.Content
+		if contentChanged {
+			updateFields["content"] = *params.Content
+			updateFields["version"] = current.Version + 1
+		}
+		if len(updateFields) == 0 {
+			return nil
+		}
+
+		if err := templateRepo.Update(params.ID, updateFields); err != nil {
+			return err
+		}
+		if !contentChanged {
+			return nil
+		}
+		return templateRepo.CreateVersion(&models.TemplateVersion{
+			TemplateID: params.ID,
+			Version:    current.Version + 1,
+			Content:    *params.Content,
+			Comment:    params.Comment,
+		})
+	})
+	if err != nil {
+		logger.ErrorfWithTrace(s.Ctx, "update template failed: %s", err.Error())
+		return common.NewErrorCode(common.ErrUpdateTemplate, err)
+	}
+
+	return nil
+}
+
+type RollbackParams struct {
+	ID      int64 `json:"ID" binding:"required"`      // 模板 ID
+	Version int   `json:"Version" binding:"required"` // 回滚到的版本号
+}
+
+// Rollback 将指定历史版本的内容作为新版本发布，保留完整的版本链
+func (s *Svc) Rollback(params RollbackParams) (*models.Template, error) {
+	target, err := s.getRepo().GetVersion(params.ID, params.Version)
+	if err != nil {
+		return nil, common.NewErrorCode(common.ErrRollbackTemplate, fmt.Errorf("版本 v%d 不存在: %w", params.Version, err))
+	}
+
+	var t *models.Template
+	err = gormdb.Cli(s.Ctx).Transaction(func(tx *gorm.DB) error {
+		templateRepo := factory.TemplateRepo(tx)
+		// 加锁读取当前版本，并发回滚或更新时版本号依次递增
+		if t, err = templateRepo.GetByIDForUpdate(params.ID); err != nil {
+			return fmt.Errorf("模板不存在: %w", err)
+		}
+		newVersion := t.Version + 1
+		if err := templateRepo.Update(params.ID, map[string]interface{}{
+			"content": target.Content,
+			"version": newVersion,
+		}); err != nil {
+			return err
+		}
+		t.Content = target.Content
+		t.Version = newVersion
+		return templateRepo.CreateVersion(&models.TemplateVersion{
+			TemplateID: params.ID,
+			Version:    newVersion,
+			Content:    target.Content,
+			Comment:    fmt.Sprintf("回滚至 v%d", params.Version),
+		})
+	})
+	if err != nil {
+		logger.ErrorfWithTrace(s.Ctx, "rollback template failed: %s", err.Error())
+		return nil, common.NewErrorCode(common.ErrRollbackTemplate, err)
+	}
+	return t, nil
+}
+
+type DeleteParams struct {
+	common.Test
+	IDs []int64 `json:"TemplateIds" binding:"required"` // 待删除 ID 列表
+}
+
+// Delete 删除模板及其绑定关系，绑定该模板的分组/模拟器回退到默认模板
+func (s *Svc) Delete(params DeleteParams) error {
+	err := gormdb.Cli(s.Ctx).Transaction(func(tx *gorm.DB) error {
+		templateRepo := factory.TemplateRepo(tx)
+		if err := templateRepo.DeleteBindingsByTemplateIDs(params.IDs); err != nil {
+			return fmt.Errorf("删除模板绑定失败: %w", err)
+		}
+		return templateRepo.Deletes(params.IDs)
+	})
+	if err != nil {
+		logger.ErrorfWithTrace(s.Ctx, "delete templates failed: %s", err.Error())
+		return common.NewErrorCode(common.ErrDeleteTemplate, err)
+	}
+	return nil
+}
+
+type BindParams struct {
+	TemplateID   int64  `json:"TemplateID" binding:"required,gt=0"` // 模板 ID
+	GroupID      int64  `json:"GroupID" binding:"required,gt=0"`    // 分组 ID
+	EmulatorUUID string `json:"EmulatorUUID"`                       // 模拟器 uuid，为空表示绑定到分组
+}
+
+func (s *Svc) Bind(params BindParams) error {
+	t := &models.Template{}
+	if err := s.getRepo().GetByID(t, params.TemplateID); err != nil {
+		return common.NewErrorCode(common.ErrBindTemplate, fmt.Errorf("模板不存在: %w", err))
+	}
+
+	groupAPI := group.NewGroupAPI(s.Ctx)
+	hasGroup, err := groupAPI.CheckGroupID(params.GroupID)
+	if err != nil {
+		return common.NewErrorCode(common.ErrBindTemplate, err)
+	}
+	if !hasGroup {
+		return common.NewErrorCode(common.ErrBindTemplate, errors.New("当前分组ID不是激活状态或者不存在"))
+	}
+
+	if params.EmulatorUUID != "" {
+		emulator := &models.Emulator{}
+		if err := s.getEmulatorRepo().GetByUuid(emulator, params.EmulatorUUID); err != nil {
+			return common.NewErrorCode(common.ErrBindTemplate, fmt.Errorf("模拟器不存在: %w", err))
+		}
+		if emulator.GroupID != params.GroupID {
+			return common.NewErrorCode(common.ErrBindTemplate, errors.New("模拟器不属于该分组"))
+		}
+	}
+
+	// 删除旧绑定与创建新绑定在同一事务中，避免失败时留下无绑定的中间状态
+	err = gormdb.Cli(s.Ctx).Transaction(func(tx *gorm.DB) error {
+		return factory.TemplateRepo(tx).Bind(&models.TemplateBinding{
+			TemplateID:   t.ID,
+			Format:       t.Format,
+			GroupID:      params.GroupID,
+			EmulatorUUID: params.EmulatorUUID,
+		})
+	})
+	if err != nil {
+		logger.ErrorfWithTrace(s.Ctx, "bind template failed: %s", err.Error())
+		return common.NewErrorCode(common.ErrBindTemplate, err)
+	}
+	return nil
+}
+
+type UnbindParams struct {
+	GroupID      int64  `json:"GroupID" binding:"required,gt=0"` // 分组 ID
+	EmulatorUUID string `json:"EmulatorUUID"`                    // 模拟器 uuid，为空表示解绑分组级模板
+	Format       string `json:"Format" binding:"required"`       // 订阅格式
+}
+
+func (s *Svc) Unbind(params UnbindParams) error {
+	format, err := subscribe.ParseFormat(params.Format)
+	if err != nil {
+		return common.NewErrorCode(common.ErrInvalidParams, err)
+	}
+
+	if err := s.getRepo().Unbind(params.GroupID, params.EmulatorUUID, string(format)); err != nil {
+		logger.ErrorfWithTrace(s.Ctx, "unbind template failed: %s", err.Error())
+		return common.NewErrorCode(common.ErrBindTemplate, err)
+	}
+	return nil
+}
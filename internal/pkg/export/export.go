@@ -0,0 +1,188 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// 导出格式
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"   // 单个 JSON 数组
+	FormatNDJSON = "ndjson" // 每行一个 JSON 对象
+)
+
+// ContentType 返回导出格式对应的 Content-Type
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+// Encoder 逐条写出记录，写完后必须调用 Close 输出结尾并刷新缓冲
+type Encoder interface {
+	Encode(v interface{}) error
+	Close() error
+}
+
+// NewEncoder 创建指定格式的编码器，columns 为 CSV 的列（记录 JSON 序列化后的字段名），其他格式忽略
+func NewEncoder(format string, w io.Writer, columns []string) (Encoder, error) {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case FormatCSV:
+		return &csvEncoder{w: csv.NewWriter(bw), bw: bw, columns: columns}, nil
+	case FormatJSON:
+		return &jsonEncoder{bw: bw}, nil
+	case FormatNDJSON:
+		return &ndjsonEncoder{enc: json.NewEncoder(bw), bw: bw}, nil
+	default:
+		return nil, fmt.Errorf("不支持的导出格式 %s", format)
+	}
+}
+
+type csvEncoder struct {
+	w       *csv.Writer
+	bw      *bufio.Writer
+	columns []string
+	started bool
+}
+
+// Encode 先按 JSON 序列化记录，再按列名取值，保证 CSV 与 JSON 导出的字段一致
+func (e *csvEncoder) Encode(v interface{}) error {
+	if !e.started {
+		e.started = true
+		if err := e.w.Write(e.columns); err != nil {
+			return err
+		}
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	fields := make(map[string]interface{})
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return err
+	}
+
+	record := make([]string, len(e.columns))
+	for i, c := range e.columns {
+		switch val := fields[c].(type) {
+		case nil:
+		case string:
+			record[i] = val
+		case json.Number, bool:
+			record[i] = fmt.Sprint(val)
+		default:
+			nested, _ := json.Marshal(val)
+			record[i] = string(nested)
+		}
+	}
+	return e.w.Write(record)
+}
+
+// Close 没有任何记录时也输出表头
+func (e *csvEncoder) Close() error {
+	if !e.started {
+		e.started = true
+		if err := e.w.Write(e.columns); err != nil {
+			return err
+		}
+	}
+	e.w.Flush()
+	if err := e.w.Error(); err != nil {
+		return err
+	}
+	return e.bw.Flush()
+}
+
+type jsonEncoder struct {
+	bw    *bufio.Writer
+	count int
+}
+
+func (e *jsonEncoder) Encode(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	sep := ","
+	if e.count == 0 {
+		sep = "["
+	}
+	e.count++
+	if _, err := e.bw.WriteString(sep); err != nil {
+		return err
+	}
+	_, err = e.bw.Write(b)
+	return err
+}
+
+func (e *jsonEncoder) Close() error {
+	end := "]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+	if _, err := e.bw.WriteString(end); err != nil {
+		return err
+	}
+	return e.bw.Flush()
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+	bw  *bufio.Writer
+}
+
+func (e *ndjsonEncoder) Encode(v interface{}) error {
+	return e.enc.Encode(v)
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return e.bw.Flush()
+}
+
+// Columns 按 JSON 字段名列出结构体的可导出字段，展开匿名嵌入的结构体，跳过 json:"-"
+func Columns(v interface{}) []string {
+	return columns(reflect.TypeOf(v))
+}
+
+func columns(t reflect.Type) []string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	cols := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		// 与 encoding/json 一致：匿名嵌入的结构体即使未导出，其字段也会展开
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			cols = append(cols, columns(f.Type)...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		cols = append(cols, name)
+	}
+	return cols
+}
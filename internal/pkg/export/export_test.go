@@ -0,0 +1,70 @@
+package export
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type meta struct {
+	ID      int64 `json:"Id"`
+	Deleted bool  `json:"-"`
+}
+
+type row struct {
+	meta
+	Name  string `json:"Name"`
+	Count int64  `json:"Count"`
+	Tags  []string
+}
+
+func TestColumns(t *testing.T) {
+	want := []string{"Id", "Name", "Count", "Tags"}
+	if got := Columns(&row{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestEncoders(t *testing.T) {
+	rows := []row{
+		{meta: meta{ID: 1}, Name: "a,b", Count: 1234567890123, Tags: []string{"x"}},
+		{meta: meta{ID: 2}, Name: "c"},
+	}
+	cases := []struct {
+		format string
+		want   string
+		empty  string
+	}{
+		{FormatCSV, "Id,Name,Count,Tags\n1,\"a,b\",1234567890123,\"[\"\"x\"\"]\"\n2,c,0,\n", "Id,Name,Count,Tags\n"},
+		{FormatJSON, `[{"Id":1,"Name":"a,b","Count":1234567890123,"Tags":["x"]},{"Id":2,"Name":"c","Count":0,"Tags":null}]` + "\n", "[]\n"},
+		{FormatNDJSON, `{"Id":1,"Name":"a,b","Count":1234567890123,"Tags":["x"]}` + "\n" + `{"Id":2,"Name":"c","Count":0,"Tags":null}` + "\n", ""},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		enc, err := NewEncoder(c.format, &buf, Columns(row{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range rows {
+			if err := enc.Encode(&rows[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := enc.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != c.want {
+			t.Errorf("%s: got %q, want %q", c.format, buf.String(), c.want)
+		}
+
+		buf.Reset()
+		enc, _ = NewEncoder(c.format, &buf, Columns(row{}))
+		if err := enc.Close(); err != nil || buf.String() != c.empty {
+			t.Errorf("%s empty: got %q, %v", c.format, buf.String(), err)
+		}
+	}
+
+	if _, err := NewEncoder("xml", &bytes.Buffer{}, nil); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -0,0 +1,134 @@
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Info IP 的地理位置及 ASN 信息
+type Info struct {
+	Country  string // 国家 ISO 代码，如 US
+	Region   string // 一级行政区英文名
+	City     string // 城市英文名
+	Timezone string // IANA 时区，如 America/New_York
+	ASN      int64  // 自治系统号
+	ASOrg    string // 自治系统所属组织
+}
+
+// Resolver 基于本地 mmdb 文件的查询器，city 与 asn 任一可为空；
+// 同时包含位置和 ASN 字段的库（如 dbip/ipinfo 合并库）只配置其中一个即可
+type Resolver struct {
+	city *maxminddb.Reader
+	asn  *maxminddb.Reader
+}
+
+// record GeoIP2/GeoLite2 City、ASN 库中用到的字段
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Location struct {
+		TimeZone string `maxminddb:"time_zone"`
+	} `maxminddb:"location"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	ASN   int64  `maxminddb:"autonomous_system_number"`
+	ASOrg string `maxminddb:"autonomous_system_organization"`
+}
+
+// NewResolver 打开 City 库和 ASN 库，路径为空的跳过
+func NewResolver(cityPath, asnPath string) (*Resolver, error) {
+	r := &Resolver{}
+	var err error
+	if cityPath != "" {
+		if r.city, err = maxminddb.Open(cityPath); err != nil {
+			return nil, fmt.Errorf("打开 GeoIP City 库失败: %w", err)
+		}
+	}
+	if asnPath != "" {
+		if r.asn, err = maxminddb.Open(asnPath); err != nil {
+			return nil, fmt.Errorf("打开 GeoIP ASN 库失败: %w", err)
+		}
+	}
+	return r, nil
+}
+
+// Lookup 查询 IP 信息，任何库都未收录时 found 为 false
+func (r *Resolver) Lookup(ip string) (info Info, found bool) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return info, false
+	}
+
+	for _, reader := range []*maxminddb.Reader{r.city, r.asn} {
+		if reader == nil {
+			continue
+		}
+		var rec record
+		_, ok, err := reader.LookupNetwork(parsed, &rec)
+		if err != nil || !ok {
+			continue
+		}
+		fill(&info, &rec)
+		found = true
+	}
+	return info, found
+}
+
+// fill 从查询结果中提取字段，已有值不覆盖
+func fill(info *Info, rec *record) {
+	setString(&info.Country, rec.Country.ISOCode)
+	setString(&info.City, rec.City.Names["en"])
+	setString(&info.Timezone, rec.Location.TimeZone)
+	if len(rec.Subdivisions) > 0 {
+		setString(&info.Region, rec.Subdivisions[0].Names["en"])
+	}
+	if info.ASN == 0 {
+		info.ASN = rec.ASN
+	}
+	setString(&info.ASOrg, rec.ASOrg)
+}
+
+func setString(dst *string, v string) {
+	if *dst == "" {
+		*dst = v
+	}
+}
+
+var (
+	mu       sync.RWMutex
+	resolver *Resolver
+)
+
+// Init 初始化全局查询器，两个路径都为空时不启用
+func Init(cityPath, asnPath string) error {
+	if cityPath == "" && asnPath == "" {
+		return nil
+	}
+	r, err := NewResolver(cityPath, asnPath)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	resolver = r
+	mu.Unlock()
+	return nil
+}
+
+// Lookup 使用全局查询器查询 IP 信息，未启用时 found 为 false
+func Lookup(ip string) (Info, bool) {
+	mu.RLock()
+	r := resolver
+	mu.RUnlock()
+	if r == nil {
+		return Info{}, false
+	}
+	return r.Lookup(ip)
+}
@@ -0,0 +1,254 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"sort"
+	"testing"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// 测试用 mmdb 文件按 MaxMind DB 格式构造：https://maxmind.github.io/MaxMind-DB/
+// 文件由 搜索树 + 16 字节分隔符 + 数据段 + 元数据 组成，元数据以 metadataMarker 开头位于文件末尾
+
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// dataSectionSeparator 搜索树与数据段之间的分隔符长度
+const dataSectionSeparator = 16
+
+// 数据段字段类型
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// pointer 测试用的数据段指针
+type pointer uint
+
+// encode 按 MaxMind DB 数据段格式编码，仅覆盖测试需要的类型
+func encode(v interface{}) []byte {
+	switch val := v.(type) {
+	case pointer:
+		p := uint(val)
+		if p < 2048 {
+			return []byte{typePointer<<5 | byte(p>>8&0x7), byte(p)}
+		}
+		p -= 2048
+		return []byte{typePointer<<5 | 1<<3 | byte(p>>16&0x7), byte(p >> 8), byte(p)}
+	case string:
+		return append(ctrl(typeString, len(val)), val...)
+	case uint16:
+		return append(ctrl(typeUint16, 2), byte(val>>8), byte(val))
+	case uint32:
+		b := make([]byte, 4)
+		binary.BigEndian.PutUint32(b, val)
+		return append(ctrl(typeUint32, 4), b...)
+	case bool:
+		n := 0
+		if val {
+			n = 1
+		}
+		return ctrl(typeBool, n)
+	case []interface{}:
+		out := ctrl(typeArray, len(val))
+		for _, item := range val {
+			out = append(out, encode(item)...)
+		}
+		return out
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := ctrl(typeMap, len(val))
+		for _, k := range keys {
+			out = append(out, encode(k)...)
+			out = append(out, encode(val[k])...)
+		}
+		return out
+	}
+	panic("unsupported type")
+}
+
+func ctrl(typeNum, size int) []byte {
+	var out []byte
+	var first byte
+	if typeNum > 7 {
+		first = 0
+	} else {
+		first = byte(typeNum) << 5
+	}
+
+	var extra []byte
+	switch {
+	case size < 29:
+		first |= byte(size)
+	case size < 285:
+		first |= 29
+		extra = []byte{byte(size - 29)}
+	default:
+		first |= 30
+		n := size - 285
+		extra = []byte{byte(n >> 8), byte(n)}
+	}
+	out = append(out, first)
+	if typeNum > 7 {
+		out = append(out, byte(typeNum-7))
+	}
+	return append(out, extra...)
+}
+
+type network struct {
+	cidr string
+	data int // 数据段偏移
+}
+
+// buildMMDB 构造只包含给定网段的 mmdb 文件
+func buildMMDB(t *testing.T, ipVersion, recordSize int, networks []network, data []byte) []byte {
+	t.Helper()
+
+	const empty = -1
+	type node struct{ rec [2]int } // >=0 为节点下标，<= -2 为数据偏移 -(off+2)
+	nodes := []*node{{rec: [2]int{empty, empty}}}
+
+	for _, n := range networks {
+		_, ipNet, err := net.ParseCIDR(n.cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ip := ipNet.IP
+		ones, _ := ipNet.Mask.Size()
+		if ipVersion == 6 && len(ip.To4()) == net.IPv4len {
+			ip = append(make(net.IP, 12), ip.To4()...)
+			ones += 96
+		}
+
+		cur := 0
+		for i := 0; i < ones; i++ {
+			bit := int(ip[i/8]>>(7-uint(i%8))) & 1
+			if i == ones-1 {
+				nodes[cur].rec[bit] = -(n.data + 2)
+				break
+			}
+			next := nodes[cur].rec[bit]
+			if next < 0 {
+				nodes = append(nodes, &node{rec: [2]int{empty, empty}})
+				next = len(nodes) - 1
+				nodes[cur].rec[bit] = next
+			}
+			cur = next
+		}
+	}
+
+	nodeCount := len(nodes)
+	resolve := func(v int) uint32 {
+		switch {
+		case v == empty:
+			return uint32(nodeCount)
+		case v < 0:
+			return uint32(nodeCount + dataSectionSeparator + (-v - 2))
+		default:
+			return uint32(v)
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		l, r := resolve(n.rec[0]), resolve(n.rec[1])
+		switch recordSize {
+		case 24:
+			buf.Write([]byte{byte(l >> 16), byte(l >> 8), byte(l), byte(r >> 16), byte(r >> 8), byte(r)})
+		case 28:
+			buf.Write([]byte{byte(l >> 16), byte(l >> 8), byte(l), byte(l>>20)&0xf0 | byte(r>>24)&0x0f, byte(r >> 16), byte(r >> 8), byte(r)})
+		case 32:
+			b := make([]byte, 8)
+			binary.BigEndian.PutUint32(b, l)
+			binary.BigEndian.PutUint32(b[4:], r)
+			buf.Write(b)
+		}
+	}
+	buf.Write(make([]byte, dataSectionSeparator))
+	buf.Write(data)
+	buf.Write(metadataMarker)
+	buf.Write(encode(map[string]interface{}{
+		"node_count":    uint32(nodeCount),
+		"record_size":   uint16(recordSize),
+		"ip_version":    uint16(ipVersion),
+		"database_type": "Test-City",
+	}))
+	return buf.Bytes()
+}
+
+// testData 返回数据段及两条记录的偏移；第二条记录通过指针复用第一条记录中的字符串
+func testData() (data []byte, us, jp int) {
+	shared := encode("shared-org")
+	data = append(data, shared...)
+
+	us = len(data)
+	data = append(data, encode(map[string]interface{}{
+		"country":  map[string]interface{}{"iso_code": "US"},
+		"city":     map[string]interface{}{"names": map[string]interface{}{"en": "New York"}},
+		"location": map[string]interface{}{"time_zone": "America/New_York"},
+		"subdivisions": []interface{}{
+			map[string]interface{}{"names": map[string]interface{}{"en": "New York"}},
+		},
+		"autonomous_system_number":       uint32(7922),
+		"autonomous_system_organization": pointer(0),
+		"is_anycast":                     false,
+	})...)
+
+	jp = len(data)
+	data = append(data, encode(map[string]interface{}{
+		"country":                        map[string]interface{}{"iso_code": "JP"},
+		"autonomous_system_organization": pointer(0),
+	})...)
+	return data, us, jp
+}
+
+func TestResolverLookup(t *testing.T) {
+	data, us, jp := testData()
+	for _, ipVersion := range []int{4, 6} {
+		for _, recordSize := range []int{24, 28, 32} {
+			reader, err := maxminddb.FromBytes(buildMMDB(t, ipVersion, recordSize, []network{
+				{cidr: "1.2.3.0/24", data: us},
+				{cidr: "5.6.0.0/16", data: jp},
+			}, data))
+			if err != nil {
+				t.Fatalf("v%d/%d: %v", ipVersion, recordSize, err)
+			}
+			r := &Resolver{city: reader}
+
+			info, found := r.Lookup("1.2.3.4")
+			want := Info{Country: "US", Region: "New York", City: "New York", Timezone: "America/New_York", ASN: 7922, ASOrg: "shared-org"}
+			if !found || info != want {
+				t.Errorf("v%d/%d: got %+v, want %+v", ipVersion, recordSize, info, want)
+			}
+			if info, found := r.Lookup("5.6.200.1"); !found || info.Country != "JP" || info.ASOrg != "shared-org" {
+				t.Errorf("v%d/%d: 5.6.200.1 got %+v", ipVersion, recordSize, info)
+			}
+			if _, found := r.Lookup("9.9.9.9"); found {
+				t.Errorf("v%d/%d: 9.9.9.9 不应命中", ipVersion, recordSize)
+			}
+			if _, found := r.Lookup("not-an-ip"); found {
+				t.Error("无效 IP 不应命中")
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package probe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// maxExitIPBody 出口 IP 接口响应体的最大读取长度
+const maxExitIPBody = 4 << 10
+
+// ExitIP 经代理请求 endpoint（"what is my IP" 类接口）获取出口 IP，
+// 响应体支持纯文本 IP，或包含 ip / origin / query 字段的 JSON
+func ExitIP(ctx context.Context, t Target, endpoint string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var auth *proxy.Auth
+	if t.Username != "" {
+		auth = &proxy.Auth{User: t.Username, Password: t.Password}
+	}
+	dialer, err := proxy.SOCKS5("tcp", t.Addr(), auth, &net.Dialer{Timeout: timeout})
+	if err != nil {
+		return "", fmt.Errorf("创建 SOCKS5 拨号器失败: %w", err)
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext:       dialer.(proxy.ContextDialer).DialContext,
+			DisableKeepAlives: true,
+		},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("构造出口 IP 请求失败: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("经代理 %s 请求 %s 失败: %w", t.Addr(), endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("出口 IP 接口返回状态码 %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxExitIPBody))
+	if err != nil {
+		return "", fmt.Errorf("读取出口 IP 响应失败: %w", err)
+	}
+	return ParseExitIP(body)
+}
+
+// ParseExitIP 从出口 IP 接口的响应体中解析 IP
+func ParseExitIP(body []byte) (string, error) {
+	text := strings.TrimSpace(string(body))
+
+	candidate := text
+	if strings.HasPrefix(text, "{") {
+		var payload map[string]interface{}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return "", fmt.Errorf("解析出口 IP 响应失败: %w", err)
+		}
+		candidate = ""
+		for _, key := range []string{"ip", "origin", "query"} {
+			if v, ok := payload[key].(string); ok {
+				candidate = v
+				break
+			}
+		}
+	}
+
+	// httpbin 的 origin 可能是 "a, b" 形式，取第一个
+	candidate = strings.TrimSpace(strings.Split(candidate, ",")[0])
+	ip := net.ParseIP(candidate)
+	if ip == nil {
+		return "", fmt.Errorf("出口 IP 响应无法识别: %q", text)
+	}
+	return ip.String(), nil
+}
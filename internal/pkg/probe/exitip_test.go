@@ -0,0 +1,54 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseExitIP(t *testing.T) {
+	cases := map[string]string{
+		"1.2.3.4\n":                          "1.2.3.4",
+		`{"ip":"1.2.3.4"}`:                   "1.2.3.4",
+		`{"origin":"1.2.3.4, 5.6.7.8"}`:      "1.2.3.4",
+		`{"query":"2001:db8::1","org":"x"}`:  "2001:db8::1",
+		`{"status":"success","query":"::1"}`: "::1",
+	}
+	for body, want := range cases {
+		got, err := ParseExitIP([]byte(body))
+		if err != nil || got != want {
+			t.Errorf("ParseExitIP(%q) = %q, %v, want %q", body, got, err, want)
+		}
+	}
+
+	for _, body := range []string{"", "not an ip", `{"ip":1}`, `{"addr":"1.2.3.4"}`} {
+		if _, err := ParseExitIP([]byte(body)); err == nil {
+			t.Errorf("ParseExitIP(%q) 应返回错误", body)
+		}
+	}
+}
+
+func TestExitIP(t *testing.T) {
+	// 本地 echo 服务：返回请求方的 IP，经过本地代理时即 127.0.0.1
+	echo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		_, _ = fmt.Fprintf(w, `{"ip":%q}`, host)
+	}))
+	defer echo.Close()
+
+	host, port := fakeSOCKS5(t, "user", "pass")
+	ctx := context.Background()
+
+	got, err := ExitIP(ctx, Target{Server: host, Port: port, Username: "user", Password: "pass"}, echo.URL, time.Second)
+	if err != nil || got != "127.0.0.1" {
+		t.Fatalf("ExitIP = %q, %v, want 127.0.0.1", got, err)
+	}
+
+	if _, err := ExitIP(ctx, Target{Server: host, Port: port, Username: "user", Password: "wrong"}, echo.URL, time.Second); err == nil {
+		t.Fatal("认证失败时应返回错误")
+	}
+}
@@ -0,0 +1,48 @@
+package probe
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// Target 被探测的 SOCKS5 代理
+type Target struct {
+	Server   string
+	Port     int64
+	Username string
+	Password string
+}
+
+// Addr 返回代理地址 host:port
+func (t Target) Addr() string {
+	return net.JoinHostPort(t.Server, strconv.FormatInt(t.Port, 10))
+}
+
+// SOCKS5 通过代理完成 SOCKS5 握手及带认证的 CONNECT，连接成功即视为可用，返回整个过程的耗时
+func SOCKS5(ctx context.Context, t Target, dest string, timeout time.Duration) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var auth *proxy.Auth
+	if t.Username != "" {
+		auth = &proxy.Auth{User: t.Username, Password: t.Password}
+	}
+	dialer, err := proxy.SOCKS5("tcp", t.Addr(), auth, &net.Dialer{Timeout: timeout})
+	if err != nil {
+		return 0, fmt.Errorf("创建 SOCKS5 拨号器失败: %w", err)
+	}
+
+	start := time.Now()
+	conn, err := dialer.(proxy.ContextDialer).DialContext(ctx, "tcp", dest)
+	if err != nil {
+		return 0, fmt.Errorf("经代理 %s 连接 %s 失败: %w", t.Addr(), dest, err)
+	}
+	latency := time.Since(start)
+	_ = conn.Close()
+	return latency, nil
+}
@@ -0,0 +1,151 @@
+package probe
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fakeSOCKS5 启动一个仅支持用户名密码认证和 CONNECT 的本地 SOCKS5 服务，返回监听地址
+func fakeSOCKS5(t *testing.T, user, pass string) (string, int64) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSOCKS5(conn, user, pass)
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	p, _ := strconv.ParseInt(port, 10, 64)
+	return host, p
+}
+
+func serveSOCKS5(conn net.Conn, user, pass string) {
+	defer conn.Close()
+
+	// 协商认证方式：VER NMETHODS METHODS
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, make([]byte, head[1])); err != nil {
+		return
+	}
+	_, _ = conn.Write([]byte{0x05, 0x02})
+
+	// 用户名密码认证：VER ULEN UNAME PLEN PASSWD
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return
+	}
+	uname := make([]byte, buf[1])
+	_, _ = io.ReadFull(conn, uname)
+	plen := make([]byte, 1)
+	_, _ = io.ReadFull(conn, plen)
+	passwd := make([]byte, plen[0])
+	_, _ = io.ReadFull(conn, passwd)
+	if string(uname) != user || string(passwd) != pass {
+		_, _ = conn.Write([]byte{0x01, 0x01})
+		return
+	}
+	_, _ = conn.Write([]byte{0x01, 0x00})
+
+	// CONNECT 请求：VER CMD RSV ATYP DST.ADDR DST.PORT
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(conn, req); err != nil {
+		return
+	}
+	var host string
+	switch req[3] {
+	case 0x01:
+		addr := make([]byte, 4)
+		_, _ = io.ReadFull(conn, addr)
+		host = net.IP(addr).String()
+	case 0x03:
+		l := make([]byte, 1)
+		_, _ = io.ReadFull(conn, l)
+		addr := make([]byte, l[0])
+		_, _ = io.ReadFull(conn, addr)
+		host = string(addr)
+	case 0x04:
+		addr := make([]byte, 16)
+		_, _ = io.ReadFull(conn, addr)
+		host = net.IP(addr).String()
+	}
+	portBuf := make([]byte, 2)
+	_, _ = io.ReadFull(conn, portBuf)
+	dest := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(portBuf))))
+
+	upstream, err := net.DialTimeout("tcp", dest, time.Second)
+	if err != nil {
+		_, _ = conn.Write([]byte{0x05, 0x05, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer upstream.Close()
+	_, _ = conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 127, 0, 0, 1, 0, 0})
+
+	// 双向转发
+	go func() { _, _ = io.Copy(upstream, conn) }()
+	_, _ = io.Copy(conn, upstream)
+}
+
+// localTarget 启动一个只接受连接的本地 TCP 服务作为 CONNECT 目标
+func localTarget(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestSOCKS5(t *testing.T) {
+	host, port := fakeSOCKS5(t, "user", "pass")
+	dest := localTarget(t)
+	ctx := context.Background()
+
+	if _, err := SOCKS5(ctx, Target{Server: host, Port: port, Username: "user", Password: "pass"}, dest, time.Second); err != nil {
+		t.Fatalf("认证正确时应成功: %v", err)
+	}
+
+	if _, err := SOCKS5(ctx, Target{Server: host, Port: port, Username: "user", Password: "wrong"}, dest, time.Second); err == nil {
+		t.Fatal("认证失败时应返回错误")
+	}
+
+	// 关闭的端口
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().(*net.TCPAddr)
+	_ = ln.Close()
+	if _, err := SOCKS5(ctx, Target{Server: "127.0.0.1", Port: int64(addr.Port), Username: "user", Password: "pass"}, dest, time.Second); err == nil {
+		t.Fatal("代理不可达时应返回错误")
+	}
+
+	// 目标不可达
+	if _, err := SOCKS5(ctx, Target{Server: host, Port: port, Username: "user", Password: "pass"}, "127.0.0.1:1", time.Second); err == nil {
+		t.Fatal("目标不可达时应返回错误")
+	}
+}
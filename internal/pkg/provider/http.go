@@ -0,0 +1,89 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	callAPi "github.com/maxliu9403/ProxyHub/internal/pkg/callAPI"
+)
+
+// HTTPConfig 通用 HTTP 适配器配置
+type HTTPConfig struct {
+	Source   string        // 来源类型
+	Endpoint string        // 接口地址前缀，如 https://api.example.com/v1
+	APIKey   string        // 鉴权密钥，通过 Authorization: Bearer 头传递
+	Timeout  time.Duration // 单次请求超时
+}
+
+// HTTPAdapter 通用 HTTP 适配器，服务商（或其前置网关）按以下约定提供 JSON 接口，均为 POST：
+//
+//	{Endpoint}/list     请求 {}                    返回 Data 为代理列表
+//	{Endpoint}/purchase 请求 PurchaseRequest       返回 Data 为新购代理列表
+//	{Endpoint}/renew    请求 {"IDs":[],"Days":n}   返回 Data 为空
+//	{Endpoint}/release  请求 {"IDs":[]}            返回 Data 为空
+//
+// 响应统一为 {"Code":0,"Msg":"","Data":...}，Code 非 0 视为失败。
+type HTTPAdapter struct {
+	cfg HTTPConfig
+}
+
+func NewHTTPAdapter(cfg HTTPConfig) *HTTPAdapter {
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &HTTPAdapter{cfg: cfg}
+}
+
+type response struct {
+	Code int     `json:"Code"`
+	Msg  string  `json:"Msg"`
+	Data []Proxy `json:"Data"`
+}
+
+type idsRequest struct {
+	IDs  []string `json:"IDs"`
+	Days int      `json:"Days,omitempty"`
+}
+
+func (a *HTTPAdapter) Source() string {
+	return a.cfg.Source
+}
+
+func (a *HTTPAdapter) List(ctx context.Context) ([]Proxy, error) {
+	return a.call(ctx, "list", struct{}{})
+}
+
+func (a *HTTPAdapter) Purchase(ctx context.Context, req PurchaseRequest) ([]Proxy, error) {
+	return a.call(ctx, "purchase", req)
+}
+
+func (a *HTTPAdapter) Renew(ctx context.Context, ids []string, days int) error {
+	_, err := a.call(ctx, "renew", idsRequest{IDs: ids, Days: days})
+	return err
+}
+
+func (a *HTTPAdapter) Release(ctx context.Context, ids []string) error {
+	_, err := a.call(ctx, "release", idsRequest{IDs: ids})
+	return err
+}
+
+func (a *HTTPAdapter) call(ctx context.Context, action string, params interface{}) ([]Proxy, error) {
+	header := map[string]string{"Content-Type": "application/json", "operator": "ProxyHub", "User-Agent": "ProxyHub"}
+	if a.cfg.APIKey != "" {
+		header["Authorization"] = "Bearer " + a.cfg.APIKey
+	}
+
+	var resp response
+	err := callAPi.CallAPI(ctx, a.cfg.Endpoint+"/"+action, params, &resp,
+		callAPi.SetHeader(header), callAPi.SetTimeout(a.cfg.Timeout), callAPi.SetMethod(callAPi.HTTPPost))
+	if err != nil {
+		return nil, fmt.Errorf("%s %s 请求失败: %w", a.cfg.Source, action, err)
+	}
+	if resp.Code != 0 {
+		return nil, fmt.Errorf("%s %s 返回错误 %d: %s", a.cfg.Source, action, resp.Code, resp.Msg)
+	}
+	return resp.Data, nil
+}
@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// fakeProvider 按 HTTPAdapter 约定实现的本地服务商
+type fakeProvider struct {
+	mu      sync.Mutex
+	proxies map[string]Proxy
+	nextID  int
+	renewed map[string]int
+	auth    string
+}
+
+func newFakeProvider(t *testing.T) (*fakeProvider, *httptest.Server) {
+	f := &fakeProvider{proxies: make(map[string]Proxy), renewed: make(map[string]int)}
+	srv := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeProvider) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.auth = r.Header.Get("Authorization")
+	reply := func(code int, msg string, data []Proxy) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"Code": code, "Msg": msg, "Data": data})
+	}
+
+	switch r.URL.Path {
+	case "/list":
+		list := make([]Proxy, 0, len(f.proxies))
+		for _, p := range f.proxies {
+			list = append(list, p)
+		}
+		reply(0, "", list)
+	case "/purchase":
+		var req PurchaseRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Count <= 0 {
+			reply(400, "count required", nil)
+			return
+		}
+		bought := make([]Proxy, 0, req.Count)
+		for i := 0; i < req.Count; i++ {
+			f.nextID++
+			p := Proxy{ID: string(rune('a' + f.nextID - 1)), IP: "203.0.113.1", Port: int64(1080 + f.nextID), Username: "u", Password: "p", Country: req.Country}
+			f.proxies[p.ID] = p
+			bought = append(bought, p)
+		}
+		reply(0, "", bought)
+	case "/renew":
+		var req idsRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		for _, id := range req.IDs {
+			f.renewed[id] += req.Days
+		}
+		reply(0, "", nil)
+	case "/release":
+		var req idsRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		for _, id := range req.IDs {
+			delete(f.proxies, id)
+		}
+		reply(0, "", nil)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestHTTPAdapter(t *testing.T) {
+	fake, srv := newFakeProvider(t)
+	a := NewHTTPAdapter(HTTPConfig{Source: "fake", Endpoint: srv.URL + "/", APIKey: "secret"})
+	ctx := context.Background()
+
+	bought, err := a.Purchase(ctx, PurchaseRequest{Count: 2, Country: "DE", Days: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bought) != 2 || bought[0].Country != "DE" {
+		t.Fatalf("unexpected purchase result: %+v", bought)
+	}
+	if fake.auth != "Bearer secret" {
+		t.Errorf("Authorization = %q", fake.auth)
+	}
+
+	if err := a.Renew(ctx, []string{bought[0].ID}, 7); err != nil {
+		t.Fatal(err)
+	}
+	if fake.renewed[bought[0].ID] != 7 {
+		t.Errorf("renewed days = %d, want 7", fake.renewed[bought[0].ID])
+	}
+
+	if err := a.Release(ctx, []string{bought[1].ID}); err != nil {
+		t.Fatal(err)
+	}
+	list, err := a.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].ID != bought[0].ID {
+		t.Errorf("list after release = %+v", list)
+	}
+
+	// 业务错误码
+	if _, err := a.Purchase(ctx, PurchaseRequest{}); err == nil {
+		t.Error("expected error for Code != 0")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	Register(NewHTTPAdapter(HTTPConfig{Source: "zz-test"}))
+	a, ok := Get("zz-test")
+	if !ok || a.Source() != "zz-test" {
+		t.Fatalf("Get(zz-test) = %v, %v", a, ok)
+	}
+	if _, ok := Get("missing"); ok {
+		t.Error("Get(missing) should fail")
+	}
+	sources := Sources()
+	if len(sources) == 0 || sources[len(sources)-1] != "zz-test" {
+		t.Errorf("Sources() = %v", sources)
+	}
+}
@@ -0,0 +1,80 @@
+// Package provider 代理服务商适配层，按 models.Proxy.Source 注册各服务商的适配器
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Proxy 服务商侧的一条代理
+type Proxy struct {
+	ID         string `json:"ID"`         // 服务商侧的代理ID，续费和释放时使用
+	IP         string `json:"IP"`         // 入口IP
+	Port       int64  `json:"Port"`       // 端口
+	Username   string `json:"Username"`   // 用户名
+	Password   string `json:"Password"`   // 密码
+	ProxyType  string `json:"ProxyType"`  // 代理类型，如 socks5
+	Country    string `json:"Country"`    // 服务商标注的国家ISO代码
+	ExpireTime int64  `json:"ExpireTime"` // 到期时间，0 表示未知
+}
+
+// Addr 代理的 ip:port
+func (p Proxy) Addr() string {
+	return fmt.Sprintf("%s:%d", p.IP, p.Port)
+}
+
+// PurchaseRequest 购买参数
+type PurchaseRequest struct {
+	Count   int    `json:"Count"`   // 购买数量
+	Country string `json:"Country"` // 国家ISO代码，为空不限制
+	Region  string `json:"Region"`  // 一级行政区，为空不限制
+	Days    int    `json:"Days"`    // 购买时长，单位天
+}
+
+// Adapter 代理服务商适配器
+type Adapter interface {
+	// Source 适配器对应的来源类型，与 models.Proxy.Source 一致
+	Source() string
+	// List 列出服务商账户下当前有效的代理
+	List(ctx context.Context) ([]Proxy, error)
+	// Purchase 购买代理并返回新代理
+	Purchase(ctx context.Context, req PurchaseRequest) ([]Proxy, error)
+	// Renew 为代理续费 days 天
+	Renew(ctx context.Context, ids []string, days int) error
+	// Release 释放代理，释放后服务商不再列出
+	Release(ctx context.Context, ids []string) error
+}
+
+var (
+	mu       sync.RWMutex
+	adapters = make(map[string]Adapter)
+)
+
+// Register 注册适配器，同一来源重复注册时后者覆盖前者
+func Register(a Adapter) {
+	mu.Lock()
+	defer mu.Unlock()
+	adapters[a.Source()] = a
+}
+
+// Get 按来源类型获取适配器
+func Get(source string) (Adapter, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	a, ok := adapters[source]
+	return a, ok
+}
+
+// Sources 返回已注册的来源类型，按名称排序
+func Sources() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	sources := make([]string, 0, len(adapters))
+	for s := range adapters {
+		sources = append(sources, s)
+	}
+	sort.Strings(sources)
+	return sources
+}
@@ -17,9 +17,17 @@ type Meta struct {
 
 type GetListParams struct {
 	types.BasicQuery          // Limit, Offset, Keyword, Order 等
-	IPs              []string `json:"IPs,omitempty"`      // 多个 IP 精准匹配
-	Ports            []int    `json:"Ports,omitempty"`    // 多端口匹配（如需）
-	GroupIDs         []int64  `json:"GroupIDs,omitempty"` // 多组 ID 过滤
+	IPs              []string `json:"IPs,omitempty"`        // 多个 IP 精准匹配
+	Ports            []int    `json:"Ports,omitempty"`      // 多端口匹配（如需）
+	GroupIDs         []int64  `json:"GroupIDs,omitempty"`   // 多组 ID 过滤
+	Countries        []string `json:"Countries,omitempty"`  // 国家 ISO 代码过滤
+	Regions          []string `json:"Regions,omitempty"`    // 一级行政区过滤
+	Cities           []string `json:"Cities,omitempty"`     // 城市过滤
+	Timezones        []string `json:"Timezones,omitempty"`  // 时区过滤
+	ASNs             []int64  `json:"ASNs,omitempty"`       // 自治系统号过滤
+	InCooldown       *bool    `json:"InCooldown,omitempty"` // 是否处于释放冷却期
+	Sources          []string `json:"Sources,omitempty"`    // 来源类型过滤
+	ProxyTypes       []string `json:"ProxyTypes,omitempty"` // 代理类型过滤
 }
 
 type GetTokenListParams struct {
@@ -38,3 +46,19 @@ type GetGroupListParams struct {
 	types.BasicQuery          // Limit, Offset, Keyword, Order 等
 	Name             []string `json:"Name,omitempty"` // 分组名称过滤
 }
+
+type GetTemplateListParams struct {
+	types.BasicQuery          // Limit, Offset, Keyword, Order 等
+	Names            []string `json:"Names,omitempty"`   // 模板名称过滤
+	Formats          []string `json:"Formats,omitempty"` // 订阅格式过滤
+}
+
+type GetBindingHistoryListParams struct {
+	types.BasicQuery          // Limit, Offset, Keyword, Order 等
+	EmulatorUUIDs    []string `json:"EmulatorUUIDs,omitempty"` // 模拟器 uuid 过滤
+	IPs              []string `json:"IPs,omitempty"`           // 代理 IP 过滤
+	GroupIDs         []int64  `json:"GroupIDs,omitempty"`      // 分组 ID 过滤
+	Reasons          []string `json:"Reasons,omitempty"`       // 绑定或解绑原因过滤
+	StartTime        int64    `json:"StartTime,omitempty"`     // 时间范围起点，返回与 [StartTime, EndTime] 有交集的绑定期
+	EndTime          int64    `json:"EndTime,omitempty"`       // 时间范围终点，为 0 不限制
+}
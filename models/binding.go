@@ -0,0 +1,22 @@
+package models
+
+// 绑定/解绑原因
+const (
+	BindReasonBind    = "bind"    // 订阅时首次绑定
+	BindReasonRotate  = "rotate"  // 订阅时按轮换策略或显式要求更换IP
+	BindReasonManual  = "manual"  // 通过接口手动指定IP
+	BindReasonRelease = "release" // 删除模拟器释放IP
+	BindReasonExpire  = "expire"  // 过期模拟器被定时任务清理释放IP
+)
+
+// BindingHistory 模拟器与代理IP的绑定历史，每条记录对应一段绑定期，UnbindTime 为 0 表示仍在绑定中
+type BindingHistory struct {
+	Meta
+	EmulatorUUID string `json:"EmulatorUUID" gorm:"column:emulator_uuid;type:varchar(128);not null;index:idx_emulator_bind;comment:'模拟器uuid'"`
+	GroupID      int64  `json:"GroupID" gorm:"column:group_id;not null;index;comment:'绑定时模拟器所在分组ID'"`
+	IP           string `json:"IP" gorm:"column:ip;type:varchar(64);not null;index:idx_ip_bind;comment:'代理IP'"`
+	BindReason   string `json:"BindReason" gorm:"column:bind_reason;type:varchar(32);not null;comment:'绑定原因：bind | rotate | manual'"`
+	BindTime     int64  `json:"BindTime" gorm:"column:bind_time;not null;index:idx_emulator_bind;index:idx_ip_bind;comment:'绑定时间'"`
+	UnbindReason string `json:"UnbindReason" gorm:"column:unbind_reason;type:varchar(32);not null;default:'';comment:'解绑原因：rotate | manual | release | expire'"`
+	UnbindTime   int64  `json:"UnbindTime" gorm:"column:unbind_time;not null;default:0;index;comment:'解绑时间，0 表示仍在绑定中'"`
+}
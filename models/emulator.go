@@ -6,11 +6,21 @@ type Emulator struct {
 	UUID      string `json:"UUID" gorm:"column:uuid;uniqueIndex;comment:模拟器uuid"`
 	GroupID   int64  `json:"GroupID" gorm:"index;column:group_id;comment:'分组ID'"`
 	IP        string `json:"IP" gorm:"index;column:ip;comment:'IP'"`
+	BackupIPs string `json:"BackupIPs" gorm:"column:backup_ips;type:varchar(1024);not null;default:'';comment:'最近一次订阅下发的备用IP，逗号分隔'"`
+	BoundAt   int64  `json:"BoundAt" gorm:"column:bound_at;not null;default:0;comment:'当前IP绑定开始时间'"`
+	Country   string `json:"Country" gorm:"column:country;type:varchar(8);not null;default:'';comment:'要求的出口国家ISO代码，为空不限制'"`
+	Region    string `json:"Region" gorm:"column:region;type:varchar(128);not null;default:'';comment:'要求的出口一级行政区，为空不限制'"`
+}
+
+// GeoConstrained 是否配置了出口地区要求
+func (e *Emulator) GeoConstrained() bool {
+	return e.Country != "" || e.Region != ""
 }
 
 type EmulatorBrief struct {
 	BrowserID     string `json:"BrowserID"`
 	UUID          string `json:"UUID"`
 	IP            string `json:"IP"`
+	BackupIPs     string `json:"BackupIPs"`
 	SubscribeLink string `json:"SubscribeLink"`
 }
@@ -0,0 +1,151 @@
+package factory
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/maxliu9403/ProxyHub/models"
+	"github.com/maxliu9403/ProxyHub/models/repo"
+	"github.com/maxliu9403/common/gadget"
+	"github.com/maxliu9403/common/gormdb"
+	"github.com/maxliu9403/common/rsql"
+	"gorm.io/gorm"
+)
+
+type bindingHistoryCrudImpl struct {
+	Conn *gorm.DB
+}
+
+func BindingHistoryRepo(db *gorm.DB) repo.BindingHistoryRepo {
+	return &bindingHistoryCrudImpl{Conn: db}
+}
+
+func (r *bindingHistoryCrudImpl) GetList(q models.GetBindingHistoryListParams, model, list interface{}) (total int64, err error) {
+	db := r.Conn.Model(model)
+
+	// 指定字段
+	if len(q.Fields) > 0 {
+		db.Select(strings.Join(q.Fields, ", "))
+	}
+
+	parseColumnFunc := func(s string) string { return r.Conn.NamingStrategy.ColumnName("", s) }
+
+	// 精确字段模糊匹配
+	if len(q.FuzzyField) > 0 {
+		for k, v := range q.FuzzyField {
+			columnName := parseColumnFunc(k)
+			db.Scopes(gormdb.KeywordGenerator([]string{columnName}, v))
+		}
+	}
+
+	// 全局模糊
+	if q.Keyword != "" {
+		fields := gadget.FieldsFromModel(model, db, true).GetStringField()
+		db.Scopes(gormdb.KeywordGenerator(fields, q.Keyword))
+	}
+
+	if len(q.EmulatorUUIDs) > 0 {
+		db.Where("emulator_uuid IN ?", q.EmulatorUUIDs)
+	}
+
+	if len(q.IPs) > 0 {
+		db.Where("ip IN ?", q.IPs)
+	}
+
+	if len(q.GroupIDs) > 0 {
+		db.Where("group_id IN ?", q.GroupIDs)
+	}
+
+	if len(q.Reasons) > 0 {
+		db.Where("bind_reason IN ? OR unbind_reason IN ?", q.Reasons, q.Reasons)
+	}
+
+	// 时间范围：绑定期与 [StartTime, EndTime] 有交集，仍在绑定中的记录视为持续到现在
+	if q.StartTime > 0 {
+		db.Where("unbind_time = 0 OR unbind_time >= ?", q.StartTime)
+	}
+	if q.EndTime > 0 {
+		db.Where("bind_time <= ?", q.EndTime)
+	}
+
+	// 自定义查询条件
+	if q.Query != "" {
+		// 把传递过来的Query字段通过gorm的字段命名策略转义成数据库字段
+		preParser, e := rsql.NewPreParser(rsql.MysqlPre(parseColumnFunc))
+		if e != nil {
+			err = e
+			return total, err
+		}
+
+		preStmt, values, err := preParser.ProcessPre(q.Query)
+		if err != nil {
+			return total, err
+		}
+
+		db.Where(preStmt, values...)
+	}
+
+	// 排序，默认按绑定时间倒序
+	if q.Order != "" {
+		orderList := strings.Split(q.Order, ",")
+		for _, o := range orderList {
+			orderKey := strings.Split(o, " ")
+			switch len(orderKey) {
+			case 1:
+				columnName := parseColumnFunc(orderKey[0])
+				db.Order(columnName)
+			case 2:
+				columnName := parseColumnFunc(orderKey[0])
+				order := strings.ToUpper(orderKey[1])
+				if order != "DESC" && order != "ASC" {
+					order = "ASC"
+				}
+				db.Order(fmt.Sprintf("%s %s", columnName, order))
+			}
+		}
+	} else {
+		db.Order("bind_time DESC")
+	}
+
+	// 计数
+	db = db.Count(&total)
+
+	// 分页
+	if q.Limit > 0 && q.Offset >= 0 {
+		db.Limit(q.Limit).Offset(q.Offset)
+	}
+
+	err = db.Find(list).Error
+
+	return total, err
+}
+
+func (r *bindingHistoryCrudImpl) Create(record *models.BindingHistory) error {
+	return r.Conn.Create(record).Error
+}
+
+// CloseByUuids 结束模拟器仍在进行中的绑定期
+func (r *bindingHistoryCrudImpl) CloseByUuids(uuids []string, reason string, at int64) error {
+	if len(uuids) == 0 {
+		return nil
+	}
+	return r.Conn.Model(&models.BindingHistory{}).
+		Where("emulator_uuid IN ?", uuids).
+		Where("unbind_time = 0").
+		Updates(map[string]interface{}{
+			"unbind_reason": reason,
+			"unbind_time":   at,
+		}).Error
+}
+
+// ListRecentIPs 返回模拟器在 since 之后绑定过的IP（含仍在绑定中的），按最近绑定时间倒序去重
+func (r *bindingHistoryCrudImpl) ListRecentIPs(uuid string, since int64) ([]string, error) {
+	var ips []string
+	err := r.Conn.Model(&models.BindingHistory{}).
+		Where("emulator_uuid = ?", uuid).
+		Where("unbind_time = 0 OR unbind_time >= ?", since).
+		Group("ip").
+		Order("MAX(bind_time) DESC").
+		Pluck("ip", &ips).Error
+	return ips, err
+}
@@ -29,6 +29,54 @@ func EmulatorRepo(db *gorm.DB) repo.EmulatorRepo {
 }
 
 func (r *emulatorCrudImpl) GetList(q models.GetEmulatorListParams, model, list interface{}) (total int64, err error) {
+	db, err := r.filter(q, model)
+	if err != nil {
+		return total, err
+	}
+
+	// 计数
+	db = db.Count(&total)
+
+	// 分页
+	if q.Limit > 0 && q.Offset >= 0 {
+		db.Limit(q.Limit).Offset(q.Offset)
+	}
+
+	err = db.Find(list).Error
+
+	return total, err
+}
+
+// Iterate 按与 GetList 相同的条件逐行读取，不把结果整体加载到内存，fn 返回错误时停止
+func (r *emulatorCrudImpl) Iterate(q models.GetEmulatorListParams, fn func(*models.Emulator) error) error {
+	db, err := r.filter(q, &models.Emulator{})
+	if err != nil {
+		return err
+	}
+	if q.Limit > 0 && q.Offset >= 0 {
+		db.Limit(q.Limit).Offset(q.Offset)
+	}
+
+	rows, err := db.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row models.Emulator
+		if err := db.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// filter 构造字段、过滤条件和排序，不含分页
+func (r *emulatorCrudImpl) filter(q models.GetEmulatorListParams, model interface{}) (*gorm.DB, error) {
 	db := r.Conn.Model(model)
 
 	// 指定字段
@@ -69,13 +117,12 @@ func (r *emulatorCrudImpl) GetList(q models.GetEmulatorListParams, model, list i
 		// 把传递过来的Query字段通过gorm的字段命名策略转义成数据库字段
 		preParser, e := rsql.NewPreParser(rsql.MysqlPre(parseColumnFunc))
 		if e != nil {
-			err = e
-			return total, err
+			return nil, e
 		}
 
 		preStmt, values, err := preParser.ProcessPre(q.Query)
 		if err != nil {
-			return total, err
+			return nil, err
 		}
 
 		db.Where(preStmt, values...)
@@ -103,17 +150,7 @@ func (r *emulatorCrudImpl) GetList(q models.GetEmulatorListParams, model, list i
 		}
 	}
 
-	// 计数
-	db = db.Count(&total)
-
-	// 分页
-	if q.Limit > 0 && q.Offset >= 0 {
-		db.Limit(q.Limit).Offset(q.Offset)
-	}
-
-	err = db.Find(list).Error
-
-	return total, err
+	return db, nil
 }
 
 func (r *emulatorCrudImpl) GetByID(model interface{}, id int64) error {
@@ -176,7 +213,7 @@ func (r *emulatorCrudImpl) GetExistingUUIDs(uuids []string) ([]string, error) {
 func (r *emulatorCrudImpl) ListBriefByGroupID(groupID int64) ([]*models.EmulatorBrief, error) {
 	var list []*models.EmulatorBrief
 	err := r.Conn.Model(&models.Emulator{}).
-		Select("browser_id, uuid, ip").
+		Select("browser_id, uuid, ip, backup_ips").
 		Where("group_id = ?", groupID).
 		Scan(&list).Error
 	return list, err
@@ -0,0 +1,40 @@
+package factory
+
+import (
+	"github.com/maxliu9403/ProxyHub/models"
+	"github.com/maxliu9403/ProxyHub/models/repo"
+	"gorm.io/gorm"
+)
+
+type proxyLatencyCrudImpl struct {
+	Conn *gorm.DB
+}
+
+func ProxyLatencyRepo(db *gorm.DB) repo.ProxyLatencyRepo {
+	return &proxyLatencyCrudImpl{Conn: db}
+}
+
+func (r *proxyLatencyCrudImpl) CreateBatch(records []*models.ProxyLatency) error {
+	if len(records) == 0 {
+		return nil
+	}
+	return r.Conn.CreateInBatches(records, 500).Error
+}
+
+// ListRecent 按检测时间倒序返回代理最近的延迟记录
+func (r *proxyLatencyCrudImpl) ListRecent(proxyID int64, limit int) ([]*models.ProxyLatency, error) {
+	var list []*models.ProxyLatency
+	err := r.Conn.Model(&models.ProxyLatency{}).
+		Where("proxy_id = ?", proxyID).
+		Order("check_time DESC").
+		Limit(limit).
+		Find(&list).Error
+	return list, err
+}
+
+// DeleteBefore 物理删除过期的延迟记录
+func (r *proxyLatencyCrudImpl) DeleteBefore(before int64) error {
+	return r.Conn.Unscoped().
+		Where("check_time < ?", before).
+		Delete(&models.ProxyLatency{}).Error
+}
@@ -31,6 +31,54 @@ func ProxyRepo(db *gorm.DB) repo.ProxyRepo {
 }
 
 func (r *proxyCrudImpl) GetList(q models.GetListParams, model, list interface{}) (total int64, err error) {
+	db, err := r.filter(q, model)
+	if err != nil {
+		return total, err
+	}
+
+	// 计数
+	db = db.Count(&total)
+
+	// 分页
+	if q.Limit > 0 && q.Offset >= 0 {
+		db.Limit(q.Limit).Offset(q.Offset)
+	}
+
+	err = db.Find(list).Error
+
+	return total, err
+}
+
+// Iterate 按与 GetList 相同的条件逐行读取，不把结果整体加载到内存，fn 返回错误时停止
+func (r *proxyCrudImpl) Iterate(q models.GetListParams, fn func(*models.Proxy) error) error {
+	db, err := r.filter(q, &models.Proxy{})
+	if err != nil {
+		return err
+	}
+	if q.Limit > 0 && q.Offset >= 0 {
+		db.Limit(q.Limit).Offset(q.Offset)
+	}
+
+	rows, err := db.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row models.Proxy
+		if err := db.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// filter 构造字段、过滤条件和排序，不含分页
+func (r *proxyCrudImpl) filter(q models.GetListParams, model interface{}) (*gorm.DB, error) {
 	db := r.Conn.Model(model)
 
 	// 指定字段
@@ -65,18 +113,53 @@ func (r *proxyCrudImpl) GetList(q models.GetListParams, model, list interface{})
 		db.Where("group_id IN ?", q.GroupIDs)
 	}
 
+	if len(q.Countries) > 0 {
+		db.Where("country IN ?", q.Countries)
+	}
+
+	if len(q.Regions) > 0 {
+		db.Where("region IN ?", q.Regions)
+	}
+
+	if len(q.Cities) > 0 {
+		db.Where("city IN ?", q.Cities)
+	}
+
+	if len(q.Timezones) > 0 {
+		db.Where("timezone IN ?", q.Timezones)
+	}
+
+	if len(q.ASNs) > 0 {
+		db.Where("asn IN ?", q.ASNs)
+	}
+
+	if len(q.Sources) > 0 {
+		db.Where("source IN ?", q.Sources)
+	}
+
+	if len(q.ProxyTypes) > 0 {
+		db.Where("proxy_type IN ?", q.ProxyTypes)
+	}
+
+	if q.InCooldown != nil {
+		if *q.InCooldown {
+			db.Where("cooldown_until > ?", time.Now().Unix())
+		} else {
+			db.Where("cooldown_until <= ?", time.Now().Unix())
+		}
+	}
+
 	// 自定义查询条件
 	if q.Query != "" {
 		// 把传递过来的Query字段通过gorm的字段命名策略转义成数据库字段
 		preParser, e := rsql.NewPreParser(rsql.MysqlPre(parseColumnFunc))
 		if e != nil {
-			err = e
-			return total, err
+			return nil, e
 		}
 
 		preStmt, values, err := preParser.ProcessPre(q.Query)
 		if err != nil {
-			return total, err
+			return nil, err
 		}
 
 		db.Where(preStmt, values...)
@@ -102,17 +185,7 @@ func (r *proxyCrudImpl) GetList(q models.GetListParams, model, list interface{})
 		}
 	}
 
-	// 计数
-	db = db.Count(&total)
-
-	// 分页
-	if q.Limit > 0 && q.Offset >= 0 {
-		db.Limit(q.Limit).Offset(q.Offset)
-	}
-
-	err = db.Find(list).Error
-
-	return total, err
+	return db, nil
 }
 
 func (r *proxyCrudImpl) GetByID(model interface{}, id int64) error {
@@ -189,6 +262,18 @@ func (r *proxyCrudImpl) DecrementInUseTx(tx *gorm.DB, ip string, count int) erro
 		UpdateColumn("inuse_count", gorm.Expr("GREATEST(inuse_count - ?, 0)", count)).Error
 }
 
+// SetCooldownTx 在事务中记录 IP 的释放冷却截止时间及释放者，until 为 0 时不处理，需在 DecrementInUseTx 之后调用。
+// 冷却只在 IP 使用数降为 0 时开始：仍有其他模拟器共用时不设置；已在冷却期内的再次释放
+// 不改写截止时间和释放者，先释放者的豁免保留到冷却结束
+func (r *proxyCrudImpl) SetCooldownTx(tx *gorm.DB, ip string, now, until int64, releasedBy string) error {
+	if until <= 0 {
+		return nil
+	}
+	return tx.Model(&models.Proxy{}).
+		Where("ip = ? AND inuse_count = 0 AND cooldown_until <= ?", ip, now).
+		UpdateColumns(map[string]interface{}{"cooldown_until": until, "last_released_by": releasedBy}).Error
+}
+
 // GetByIPForUpdate 查询指定 IP 并加锁，事务中使用
 func (r *proxyCrudImpl) GetByIPForUpdate(ip string) (*models.Proxy, error) {
 	var proxy models.Proxy
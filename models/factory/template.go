@@ -0,0 +1,215 @@
+package factory
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/maxliu9403/ProxyHub/models"
+	"github.com/maxliu9403/ProxyHub/models/repo"
+	"github.com/maxliu9403/common/gadget"
+	"github.com/maxliu9403/common/gormdb"
+	"github.com/maxliu9403/common/rsql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type templateCrudImpl struct {
+	Conn *gorm.DB
+}
+
+func TemplateRepo(db *gorm.DB) repo.TemplateRepo {
+	return &templateCrudImpl{Conn: db}
+}
+
+func (r *templateCrudImpl) GetList(q models.GetTemplateListParams, model, list interface{}) (total int64, err error) {
+	db := r.Conn.Model(model)
+
+	// 指定字段
+	if len(q.Fields) > 0 {
+		db.Select(strings.Join(q.Fields, ", "))
+	}
+
+	parseColumnFunc := func(s string) string { return r.Conn.NamingStrategy.ColumnName("", s) }
+
+	// 精确字段模糊匹配
+	if len(q.FuzzyField) > 0 {
+		for k, v := range q.FuzzyField {
+			columnName := parseColumnFunc(k)
+			db.Scopes(gormdb.KeywordGenerator([]string{columnName}, v))
+		}
+	}
+
+	// 全局模糊
+	if q.Keyword != "" {
+		fields := gadget.FieldsFromModel(model, db, true).GetStringField()
+		db.Scopes(gormdb.KeywordGenerator(fields, q.Keyword))
+	}
+
+	if len(q.Names) > 0 {
+		db.Where("name IN ?", q.Names)
+	}
+
+	if len(q.Formats) > 0 {
+		db.Where("format IN ?", q.Formats)
+	}
+
+	// 自定义查询条件
+	if q.Query != "" {
+		// 把传递过来的Query字段通过gorm的字段命名策略转义成数据库字段
+		preParser, e := rsql.NewPreParser(rsql.MysqlPre(parseColumnFunc))
+		if e != nil {
+			err = e
+			return total, err
+		}
+
+		preStmt, values, err := preParser.ProcessPre(q.Query)
+		if err != nil {
+			return total, err
+		}
+
+		db.Where(preStmt, values...)
+	}
+
+	// 排序
+	if q.Order != "" {
+		orderList := strings.Split(q.Order, ",")
+		for _, o := range orderList {
+			orderKey := strings.Split(o, " ")
+			switch len(orderKey) {
+			case 1:
+				columnName := parseColumnFunc(orderKey[0])
+				db.Order(columnName)
+			case 2:
+				columnName := parseColumnFunc(orderKey[0])
+				order := strings.ToUpper(orderKey[1])
+				if order != "DESC" && order != "ASC" {
+					order = "ASC"
+				}
+				db.Order(fmt.Sprintf("%s %s", columnName, order))
+			}
+		}
+	}
+
+	// 计数
+	db = db.Count(&total)
+
+	// 分页
+	if q.Limit > 0 && q.Offset >= 0 {
+		db.Limit(q.Limit).Offset(q.Offset)
+	}
+
+	err = db.Find(list).Error
+
+	return total, err
+}
+
+func (r *templateCrudImpl) GetByID(model interface{}, id int64) error {
+	crud := gormdb.NewCRUD(r.Conn)
+	err := crud.GetByID(model, id)
+	return err
+}
+
+// GetByIDForUpdate 查询模板并加锁，事务中使用，保证版本号递增不被并发更新覆盖
+func (r *templateCrudImpl) GetByIDForUpdate(id int64) (*models.Template, error) {
+	var t models.Template
+	if err := r.Conn.Clauses(clause.Locking{Strength: "UPDATE"}).First(&t, id).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *templateCrudImpl) Create(template *models.Template) error {
+	// 只物理删除已软删除（delete_time 不为空）的同名记录，mysql唯一索引会有冲突
+	if err := r.Conn.
+		Unscoped().
+		Where("name = ?", template.Name).
+		Where("delete_time IS NOT NULL").
+		Delete(&models.Template{}).Error; err != nil {
+		return err
+	}
+	return r.Conn.Create(template).Error
+}
+
+func (r *templateCrudImpl) Update(id int64, fields map[string]interface{}) error {
+	return r.Conn.Model(&models.Template{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *templateCrudImpl) Deletes(ids []int64) error {
+	return r.Conn.Delete(&models.Template{}, ids).Error
+}
+
+func (r *templateCrudImpl) CreateVersion(version *models.TemplateVersion) error {
+	return r.Conn.Create(version).Error
+}
+
+func (r *templateCrudImpl) GetVersion(templateID int64, version int) (*models.TemplateVersion, error) {
+	v := &models.TemplateVersion{}
+	err := r.Conn.Where("template_id = ? AND version = ?", templateID, version).First(v).Error
+	return v, err
+}
+
+func (r *templateCrudImpl) ListVersions(templateID int64) ([]*models.TemplateVersion, error) {
+	var list []*models.TemplateVersion
+	err := r.Conn.Model(&models.TemplateVersion{}).
+		Where("template_id = ?", templateID).
+		Order("version DESC").
+		Find(&list).Error
+	return list, err
+}
+
+// Bind 同一分组/模拟器/格式只保留一条绑定，已有绑定会被物理删除后重建；两步需在事务中调用
+func (r *templateCrudImpl) Bind(binding *models.TemplateBinding) error {
+	if err := r.Unbind(binding.GroupID, binding.EmulatorUUID, binding.Format); err != nil {
+		return err
+	}
+	return r.Conn.Create(binding).Error
+}
+
+func (r *templateCrudImpl) Unbind(groupID int64, emulatorUUID, format string) error {
+	return r.Conn.
+		Unscoped().
+		Where("group_id = ? AND emulator_uuid = ? AND format = ?", groupID, emulatorUUID, format).
+		Delete(&models.TemplateBinding{}).Error
+}
+
+func (r *templateCrudImpl) ListBindings(templateID int64) ([]*models.TemplateBinding, error) {
+	var list []*models.TemplateBinding
+	err := r.Conn.Model(&models.TemplateBinding{}).
+		Where("template_id = ?", templateID).
+		Find(&list).Error
+	return list, err
+}
+
+func (r *templateCrudImpl) DeleteBindingsByTemplateIDs(ids []int64) error {
+	return r.Conn.
+		Unscoped().
+		Where("template_id IN ?", ids).
+		Delete(&models.TemplateBinding{}).Error
+}
+
+// Resolve 按 模拟器级 > 分组级 的优先级查找绑定模板，均未绑定时返回 nil
+func (r *templateCrudImpl) Resolve(groupID int64, emulatorUUID, format string) (*models.Template, error) {
+	var bindings []*models.TemplateBinding
+	err := r.Conn.Model(&models.TemplateBinding{}).
+		Where("group_id = ? AND format = ?", groupID, format).
+		Where("emulator_uuid IN ?", []string{emulatorUUID, ""}).
+		Order("emulator_uuid DESC").
+		Find(&bindings).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range bindings {
+		t := &models.Template{}
+		err = r.Conn.Where("id = ?", b.TemplateID).First(t).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+	return nil, nil
+}
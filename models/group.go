@@ -1,8 +1,86 @@
 package models
 
+// IP 轮换策略
+const (
+	RotationAlways   = "always"    // 每次订阅都切换 IP
+	RotationSticky   = "sticky"    // 在 StickyTTL 内保持同一 IP，过期后订阅时切换
+	RotationOnDemand = "on_demand" // 仅在显式请求时切换
+)
+
+// 代理选择策略
+const (
+	SelectLeastUsed      = "least_used"      // 负载最低的代理中随机选择
+	SelectWeightedRandom = "weighted_random" // 按剩余容量加权随机
+	SelectRoundRobin     = "round_robin"     // 分组内轮询
+	SelectConsistentHash = "consistent_hash" // 按模拟器 UUID 一致性哈希
+	SelectLowLatency     = "low_latency"     // 优先选择健康检测延迟低的代理
+)
+
+// 历史IP策略
+const (
+	HistoryNone     = "none"     // 不参考绑定历史
+	HistoryAvoid    = "avoid"    // 不分配模拟器在 HistoryDays 内用过的IP
+	HistoryAffinity = "affinity" // 容量允许时优先分配模拟器在 HistoryDays 内用过的IP
+)
+
+// DefaultReplenishDays 自动补充代理的默认购买时长，单位天
+const DefaultReplenishDays = 30
+
+// 备用代理组类型
+const (
+	BackupTypeFallback = "fallback"
+	BackupTypeURLTest  = "url-test"
+)
+
 type Groups struct {
 	Meta
 	Name        string `json:"Name" gorm:"column:name;uniqueIndex;comment:组名"`
 	MaxOnline   int    `json:"MaxOnline" gorm:"index;column:max_online;comment:'该分组内的IP最大同时在线模拟器数'"`
 	Description string `json:"Description" gorm:"index;column:description;comment:'描述'"`
+	BackupCount int    `json:"BackupCount" gorm:"column:backup_count;not null;default:0;comment:'订阅配置中附带的备用代理数，0表示不附带'"`
+	BackupType  string `json:"BackupType" gorm:"column:backup_type;type:varchar(16);not null;default:fallback;comment:'备用代理组类型，fallback/url-test'"`
+
+	RotationPolicy string `json:"RotationPolicy" gorm:"column:rotation_policy;type:varchar(16);not null;default:on_demand;comment:'IP轮换策略，always/sticky/on_demand'"`
+	StickyTTL      int64  `json:"StickyTTL" gorm:"column:sticky_ttl;not null;default:0;comment:'sticky 策略下IP保持时长，单位秒'"`
+
+	ReleaseCooldown int64 `json:"ReleaseCooldown" gorm:"column:release_cooldown;not null;default:0;comment:'IP释放后的冷却时长，单位秒，冷却期内不分配给其他模拟器，0表示不冷却'"`
+
+	HistoryPolicy string `json:"HistoryPolicy" gorm:"column:history_policy;type:varchar(16);not null;default:none;comment:'历史IP策略，none/avoid/affinity'"`
+	HistoryDays   int    `json:"HistoryDays" gorm:"column:history_days;not null;default:0;comment:'历史IP策略参考的天数'"`
+
+	ReplenishProvider   string `json:"ReplenishProvider" gorm:"column:replenish_provider;type:varchar(64);not null;default:'';comment:'自动补充代理的服务商来源类型，为空不启用'"`
+	TargetUtilization   int    `json:"TargetUtilization" gorm:"column:target_utilization;not null;default:0;comment:'目标使用率百分比，超过时补充代理，0表示不按使用率补充'"`
+	MinFreeSlots        int    `json:"MinFreeSlots" gorm:"column:min_free_slots;not null;default:0;comment:'最少空闲名额数，不足时补充代理'"`
+	ReplenishDays       int    `json:"ReplenishDays" gorm:"column:replenish_days;not null;default:30;comment:'补充代理的购买时长，单位天'"`
+	ReplenishMaxProxies int    `json:"ReplenishMaxProxies" gorm:"column:replenish_max_proxies;not null;default:0;comment:'自动补充后分组代理数上限，0表示不限制'"`
+
+	SelectStrategy string `json:"SelectStrategy" gorm:"column:select_strategy;type:varchar(32);not null;default:least_used;comment:'代理选择策略，least_used/weighted_random/round_robin/consistent_hash/low_latency'"`
+
+	ProfileUpdateInterval int    `json:"ProfileUpdateInterval" gorm:"column:profile_update_interval;not null;default:0;comment:'客户端自动更新订阅间隔，单位小时，0表示不下发'"`
+	ProfileFilename       string `json:"ProfileFilename" gorm:"column:profile_filename;type:varchar(128);not null;default:'';comment:'订阅文件名，用于 Content-Disposition'"`
+	SubscriptionUserinfo  string `json:"SubscriptionUserinfo" gorm:"column:subscription_userinfo;type:varchar(255);not null;default:'';comment:'Subscription-Userinfo 响应头，如 upload=0; download=0; total=0; expire=0'"`
+}
+
+// CooldownUntil 返回在 now 时刻释放的 IP 的冷却截止时间，未配置冷却时返回 0
+func (g *Groups) CooldownUntil(now int64) int64 {
+	if g.ReleaseCooldown <= 0 {
+		return 0
+	}
+	return now + g.ReleaseCooldown
+}
+
+// HistorySince 返回历史IP策略的查询起点，未启用时返回 0
+func (g *Groups) HistorySince(now int64) int64 {
+	if g.HistoryPolicy != HistoryAvoid && g.HistoryPolicy != HistoryAffinity || g.HistoryDays <= 0 {
+		return 0
+	}
+	return now - int64(g.HistoryDays)*86400
+}
+
+// PurchaseDays 返回自动补充代理的购买时长，未设置时按 DefaultReplenishDays 处理
+func (g *Groups) PurchaseDays() int {
+	if g.ReplenishDays <= 0 {
+		return DefaultReplenishDays
+	}
+	return g.ReplenishDays
 }
@@ -21,6 +21,11 @@ var AllTables = []interface{}{
 	&Proxy{},
 	&Token{},
 	&Emulator{},
+	&Template{},
+	&TemplateVersion{},
+	&TemplateBinding{},
+	&ProxyLatency{},
+	&BindingHistory{},
 }
 
 // NewCreateDatabaseCommand is prepared for creating database when init project
@@ -0,0 +1,11 @@
+package models
+
+// ProxyLatency 代理健康检测的延迟历史
+type ProxyLatency struct {
+	Meta
+	ProxyID   int64  `json:"ProxyID" gorm:"column:proxy_id;not null;index:idx_proxy_check;comment:'代理ID'"`
+	IP        string `json:"IP" gorm:"column:ip;type:varchar(64);not null;comment:'代理IP'"`
+	LatencyMs int64  `json:"LatencyMs" gorm:"column:latency_ms;not null;default:0;comment:'握手及 CONNECT 耗时，毫秒，失败时为0'"`
+	Success   bool   `json:"Success" gorm:"column:success;not null;comment:'检测是否成功'"`
+	CheckTime int64  `json:"CheckTime" gorm:"column:check_time;not null;index:idx_proxy_check;index;comment:'检测时间'"`
+}
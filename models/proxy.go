@@ -1,15 +1,83 @@
 package models
 
+// 代理类型
+const (
+	ProxyTypeSOCKS5 = "socks5"
+	ProxyTypeHTTP   = "http"
+	ProxyTypeSS     = "ss"
+	ProxyTypeTrojan = "trojan"
+	ProxyTypeVMess  = "vmess"
+)
+
+// 代理健康状态
+const (
+	HealthUnknown   = "unknown"   // 尚未检测
+	HealthHealthy   = "healthy"   // 最近一次检测成功
+	HealthUnhealthy = "unhealthy" // 连续失败次数达到阈值，不参与选择
+)
+
 type Proxy struct {
 	Meta
 	IP         string `json:"IP" gorm:"column:ip;type:varchar(64);not null;index:uq_proxy,unique;comment:'IP地址'"`
 	Port       int64  `json:"Port" gorm:"column:port;not null;index:uq_proxy,unique;comment:'端口'"`
 	Username   string `json:"Username" gorm:"column:username;type:varchar(128);not null;comment:'用户名'"`
 	Password   string `json:"Password" gorm:"column:password;type:varchar(128);not null;comment:'密码'"`
-	ProxyType  string `json:"ProxyType" gorm:"column:proxy_type;type:varchar(128);not null;default:socks5;comment:'代理类型，比如socks5'"`
+	ProxyType  string `json:"ProxyType" gorm:"column:proxy_type;type:varchar(128);not null;default:socks5;comment:'代理类型，socks5/http/ss/trojan/vmess'"`
+	Extra      string `json:"Extra" gorm:"column:extra;type:text;comment:'协议相关参数JSON，如 ss 的 cipher、vmess 的 uuid'"`
 	GroupID    int64  `json:"GroupID" gorm:"column:group_id;not null;index;comment:'所属代理池组'"`
 	Source     string `json:"Source" gorm:"column:source;type:varchar(64);not null;index;comment:'来源类型，例：pias5/711/ipfoxy'"` //  新增字段
+	ProviderID string `json:"ProviderID" gorm:"column:provider_id;type:varchar(128);not null;default:'';index;comment:'服务商侧的代理ID，手动录入时为空'"`
+	ExpireTime int64  `json:"ExpireTime" gorm:"column:expire_time;not null;default:0;comment:'服务商侧到期时间，0表示未知'"`
+	Orphaned   bool   `json:"Orphaned" gorm:"column:orphaned;not null;default:false;comment:'服务商已不再提供、但仍被模拟器使用，不再参与分配'"`
 	InUseCount int64  `json:"InUseCount" gorm:"column:inuse_count;not null;index;comment:'当前使用数'"`
+	Priority   int    `json:"Priority" gorm:"column:priority;not null;default:0;index;comment:'优先级，数值越大越优先，高优先级全部满载后才使用低优先级'"`
+	Weight     int    `json:"Weight" gorm:"column:weight;not null;default:1;comment:'同优先级内的选择权重'"`
+
+	HealthStatus  string `json:"HealthStatus" gorm:"column:health_status;type:varchar(16);not null;default:unknown;index;comment:'健康状态，unknown/healthy/unhealthy'"`
+	LastCheckTime int64  `json:"LastCheckTime" gorm:"column:last_check_time;not null;default:0;comment:'最近一次健康检测时间'"`
+	FailStreak    int    `json:"FailStreak" gorm:"column:fail_streak;not null;default:0;comment:'连续检测失败次数'"`
+	LatencyMs     int64  `json:"LatencyMs" gorm:"column:latency_ms;not null;default:0;index;comment:'最近一次成功检测的延迟，毫秒，0表示未知'"`
+
+	ExitIP          string `json:"ExitIP" gorm:"column:exit_ip;type:varchar(64);not null;default:'';index;comment:'最近一次检测到的出口IP'"`
+	ExitIPChanged   bool   `json:"ExitIPChanged" gorm:"column:exit_ip_changed;not null;default:false;comment:'出口IP与上次检测（首次检测时与入口IP）不一致，确认后清除'"`
+	ExitIPConflict  bool   `json:"ExitIPConflict" gorm:"column:exit_ip_conflict;not null;default:false;comment:'出口IP与同组其他代理重复'"`
+	ExitIPCheckTime int64  `json:"ExitIPCheckTime" gorm:"column:exit_ip_check_time;not null;default:0;comment:'最近一次出口IP检测时间'"`
+
+	Country  string `json:"Country" gorm:"column:country;type:varchar(8);not null;default:'';index;comment:'国家ISO代码'"`
+	Region   string `json:"Region" gorm:"column:region;type:varchar(128);not null;default:'';index;comment:'一级行政区'"`
+	City     string `json:"City" gorm:"column:city;type:varchar(128);not null;default:'';index;comment:'城市'"`
+	Timezone string `json:"Timezone" gorm:"column:timezone;type:varchar(64);not null;default:'';index;comment:'时区'"`
+	ASN      int64  `json:"ASN" gorm:"column:asn;not null;default:0;index;comment:'自治系统号'"`
+	ASOrg    string `json:"ASOrg" gorm:"column:as_org;type:varchar(255);not null;default:'';comment:'自治系统所属组织'"`
+
+	CooldownUntil  int64  `json:"CooldownUntil" gorm:"column:cooldown_until;not null;default:0;index;comment:'释放冷却截止时间，冷却期内只可分配给 LastReleasedBy'"`
+	LastReleasedBy string `json:"LastReleasedBy" gorm:"column:last_released_by;type:varchar(128);not null;default:'';comment:'开始本次冷却的模拟器uuid，冷却期内不被后续释放覆盖'"`
+}
+
+// InCooldown 代理在 now 时刻是否处于释放冷却期
+func (p *Proxy) InCooldown(now int64) bool {
+	return p.CooldownUntil > now
+}
+
+// AssignableTo 代理在 now 时刻能否分配给模拟器 uuid：冷却期内只能分配给开始本次冷却的模拟器
+func (p *Proxy) AssignableTo(uuid string, now int64) bool {
+	return !p.InCooldown(now) || p.LastReleasedBy == uuid
+}
+
+// Usable 代理是否可参与选择：健康检测未判定不可用（未检测过视为可用）、未被服务商下线，且类型可以下发
+func (p *Proxy) Usable() bool {
+	return p.HealthStatus != HealthUnhealthy && !p.Orphaned && p.Servable()
+}
+
+// Servable 订阅模板和 URI 列表目前只能渲染 socks5/http，
+// 批量导入会把 ss/trojan/vmess 报告为不支持；经其他途径入库的在渲染支持之前不参与分配和备用选择
+func (p *Proxy) Servable() bool {
+	switch p.ProxyType {
+	case "", ProxyTypeSOCKS5, ProxyTypeHTTP:
+		return true
+	default:
+		return false
+	}
 }
 
 type ProxyBrief struct {
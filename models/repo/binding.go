@@ -0,0 +1,12 @@
+package repo
+
+import (
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+type BindingHistoryRepo interface {
+	GetList(q models.GetBindingHistoryListParams, model, list interface{}) (total int64, err error)
+	Create(record *models.BindingHistory) error
+	CloseByUuids(uuids []string, reason string, at int64) error
+	ListRecentIPs(uuid string, since int64) ([]string, error)
+}
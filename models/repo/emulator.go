@@ -11,6 +11,7 @@ import (
 type EmulatorRepo interface {
 	gormdb.GetByIDCrud
 	GetList(q models.GetEmulatorListParams, model, list interface{}) (total int64, err error)
+	Iterate(q models.GetEmulatorListParams, fn func(*models.Emulator) error) error
 	Create(group *models.Emulator) error
 	Update(uuid string, fields map[string]interface{}) error
 	CreateBatch([]*models.Emulator) error
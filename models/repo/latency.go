@@ -0,0 +1,11 @@
+package repo
+
+import (
+	"github.com/maxliu9403/ProxyHub/models"
+)
+
+type ProxyLatencyRepo interface {
+	CreateBatch(records []*models.ProxyLatency) error
+	ListRecent(proxyID int64, limit int) ([]*models.ProxyLatency, error)
+	DeleteBefore(before int64) error
+}
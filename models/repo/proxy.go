@@ -16,6 +16,7 @@ import (
 type ProxyRepo interface {
 	gormdb.GetByIDCrud
 	GetList(q models.GetListParams, model, list interface{}) (total int64, err error)
+	Iterate(q models.GetListParams, fn func(*models.Proxy) error) error
 	Deletes([]int64) (err error)
 	Create(group *models.Proxy) error
 	Update(ID int64, fields map[string]interface{}) error
@@ -24,6 +25,7 @@ type ProxyRepo interface {
 	GetByIP(ip string) (*models.Proxy, error)
 	IncrementInUseTx(tx *gorm.DB, ip string, count int) error
 	DecrementInUseTx(tx *gorm.DB, ip string, count int) error
+	SetCooldownTx(tx *gorm.DB, ip string, now, until int64, releasedBy string) error
 	GetByIPForUpdate(ip string) (*models.Proxy, error)
 	ListByGroupID(groupID int64) ([]*models.ProxyBrief, error)
 }
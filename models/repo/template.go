@@ -0,0 +1,23 @@
+package repo
+
+import (
+	"github.com/maxliu9403/ProxyHub/models"
+	"github.com/maxliu9403/common/gormdb"
+)
+
+type TemplateRepo interface {
+	gormdb.GetByIDCrud
+	GetList(q models.GetTemplateListParams, model, list interface{}) (total int64, err error)
+	Create(template *models.Template) error
+	GetByIDForUpdate(id int64) (*models.Template, error)
+	Update(id int64, fields map[string]interface{}) error
+	Deletes(ids []int64) error
+	CreateVersion(version *models.TemplateVersion) error
+	GetVersion(templateID int64, version int) (*models.TemplateVersion, error)
+	ListVersions(templateID int64) ([]*models.TemplateVersion, error)
+	Bind(binding *models.TemplateBinding) error
+	Unbind(groupID int64, emulatorUUID, format string) error
+	ListBindings(templateID int64) ([]*models.TemplateBinding, error)
+	DeleteBindingsByTemplateIDs(ids []int64) error
+	Resolve(groupID int64, emulatorUUID, format string) (*models.Template, error)
+}
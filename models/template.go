@@ -0,0 +1,29 @@
+package models
+
+// Template 订阅模板，Content 为 text/template 语法
+type Template struct {
+	Meta
+	Name        string `json:"Name" gorm:"column:name;type:varchar(128);uniqueIndex;comment:'模板名'"`
+	Format      string `json:"Format" gorm:"column:format;type:varchar(32);not null;index;comment:'订阅格式，例：clash/singbox/surge'"`
+	Content     string `json:"Content" gorm:"column:content;type:mediumtext;not null;comment:'模板内容'"`
+	Version     int    `json:"Version" gorm:"column:version;not null;default:1;comment:'当前版本号'"`
+	Description string `json:"Description" gorm:"column:description;type:varchar(255);not null;default:'';comment:'描述'"`
+}
+
+// TemplateVersion 模板历史版本，每次内容变更或回滚都会新增一条
+type TemplateVersion struct {
+	Meta
+	TemplateID int64  `json:"TemplateID" gorm:"column:template_id;not null;index:uq_template_version,unique;comment:'模板ID'"`
+	Version    int    `json:"Version" gorm:"column:version;not null;index:uq_template_version,unique;comment:'版本号'"`
+	Content    string `json:"Content" gorm:"column:content;type:mediumtext;not null;comment:'模板内容'"`
+	Comment    string `json:"Comment" gorm:"column:comment;type:varchar(255);not null;default:'';comment:'变更说明'"`
+}
+
+// TemplateBinding 模板绑定关系，EmulatorUUID 为空表示分组级绑定，否则为模拟器级覆盖
+type TemplateBinding struct {
+	Meta
+	TemplateID   int64  `json:"TemplateID" gorm:"column:template_id;not null;index;comment:'模板ID'"`
+	Format       string `json:"Format" gorm:"column:format;type:varchar(32);not null;index:uq_template_binding,unique;comment:'订阅格式'"`
+	GroupID      int64  `json:"GroupID" gorm:"column:group_id;not null;index:uq_template_binding,unique;comment:'分组ID'"`
+	EmulatorUUID string `json:"EmulatorUUID" gorm:"column:emulator_uuid;type:varchar(128);not null;default:'';index:uq_template_binding,unique;comment:'模拟器uuid，为空表示分组级'"`
+}